@@ -0,0 +1,147 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"socialify/backend/models"
+	"time"
+)
+
+// maxBuckets bounds the length of an activity series. Longer series keep
+// their most recent buckets.
+const maxBuckets = 1000
+
+type ActivityResult struct {
+	Interval string `json:"interval"`
+	Metric   string `json:"metric"`
+	UserID   string `json:"userId,omitempty"`
+	// Role says whether a user's comment series counts the comments they
+	// wrote or the comments on their posts.
+	Role     string `json:"role,omitempty"`
+	Timezone string `json:"timezone"`
+	// Total is the sum of the bucket counts.
+	Total   int                     `json:"total"`
+	Buckets []models.ActivityBucket `json:"buckets"`
+	Meta
+}
+
+// Activity counts the posts or comments created in each interval of q's
+// window, oldest first. A user's comments are the ones they wrote, or with
+// RoleAuthor the ones on their posts. Intervals without any are included
+// with a zero count. An open end of the window is closed by the oldest or
+// newest post or comment.
+func (s *Service) Activity(ctx context.Context, q ActivityQuery) (ActivityResult, error) {
+	key := fmt.Sprintf("activity:%s:%s:%s:%s:%s:%s", q.Interval, q.Metric, q.UserID, q.Role, q.Location, q.Window)
+	return remember(s, key, func() (ActivityResult, error) {
+		return s.activity(ctx, q)
+	})
+}
+
+func (s *Service) activity(ctx context.Context, q ActivityQuery) (ActivityResult, error) {
+	var posts []models.Post
+	var err error
+	if q.UserID != "" && q.Role != RoleCommenter {
+		posts, err = s.Fetcher.Source.ListPostsByUser(ctx, q.UserID)
+	} else {
+		if q.Role == RoleCommenter {
+			if _, err := s.user(ctx, q.UserID); err != nil {
+				return ActivityResult{}, err
+			}
+		}
+		_, posts, err = s.posts(ctx)
+	}
+	if err != nil {
+		return ActivityResult{}, err
+	}
+
+	times := make([]time.Time, 0, len(posts))
+	if q.Metric == MetricComments {
+		commentsByPost, err := s.Fetcher.CommentsByPost(ctx, posts)
+		if err != nil {
+			return ActivityResult{}, err
+		}
+		for _, comments := range commentsByPost {
+			for _, comment := range comments {
+				if q.Role != RoleCommenter || comment.UserID == q.UserID {
+					times = append(times, comment.CreatedAt)
+				}
+			}
+		}
+	} else {
+		for _, post := range posts {
+			times = append(times, post.CreatedAt)
+		}
+	}
+	times = within(times, q.Window, func(t time.Time) time.Time { return t })
+
+	res := ActivityResult{
+		Interval: q.Interval,
+		Metric:   q.Metric,
+		UserID:   q.UserID,
+		Role:     q.Role,
+		Timezone: q.Location.String(),
+		Buckets:  make([]models.ActivityBucket, 0),
+	}
+
+	first, last := q.Window.Since, q.Window.Until
+	if !last.IsZero() {
+		last = last.Add(-time.Nanosecond)
+	}
+	for _, t := range times {
+		if q.Window.Since.IsZero() && (first.IsZero() || t.Before(first)) {
+			first = t
+		}
+		if q.Window.Until.IsZero() && (last.IsZero() || t.After(last)) {
+			last = t
+		}
+	}
+	if first.IsZero() || last.IsZero() {
+		return res, nil
+	}
+
+	counts := make(map[int64]int)
+	for _, t := range times {
+		counts[bucketStart(t, q.Interval, q.Location).UnixNano()]++
+	}
+
+	// Walk back from the newest bucket so that a long window stops at
+	// maxBuckets without visiting the rest.
+	start := bucketStart(first, q.Interval, q.Location)
+	b := bucketStart(last, q.Interval, q.Location)
+	for !b.Before(start) && len(res.Buckets) < maxBuckets {
+		count := counts[b.UnixNano()]
+		res.Buckets = append(res.Buckets, models.ActivityBucket{Start: b, Label: bucketLabel(b, q.Interval), Count: count})
+		res.Total += count
+		b = bucketStart(b.Add(-time.Nanosecond), q.Interval, q.Location)
+	}
+	for i, j := 0, len(res.Buckets)-1; i < j; i, j = i+1, j-1 {
+		res.Buckets[i], res.Buckets[j] = res.Buckets[j], res.Buckets[i]
+	}
+	return res, nil
+}
+
+// bucketStart returns the start of the interval t falls in, in loc. Days
+// start at local midnight, weeks at midnight on Monday.
+func bucketStart(t time.Time, interval string, loc *time.Location) time.Time {
+	t = t.In(loc)
+	switch interval {
+	case IntervalHour:
+		// Subtracting keeps the right hour when clocks go back and a
+		// local time happens twice.
+		return t.Add(-time.Duration(t.Minute())*time.Minute - time.Duration(t.Second())*time.Second - time.Duration(t.Nanosecond()))
+	case IntervalWeek:
+		y, m, d := t.Date()
+		return time.Date(y, m, d-(int(t.Weekday())+6)%7, 0, 0, 0, 0, loc)
+	default:
+		y, m, d := t.Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, loc)
+	}
+}
+
+// bucketLabel names the bucket starting at start for display.
+func bucketLabel(start time.Time, interval string) string {
+	if interval == IntervalHour {
+		return start.Format("2006-01-02 15:00")
+	}
+	return start.Format("2006-01-02")
+}
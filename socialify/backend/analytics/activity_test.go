@@ -0,0 +1,228 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"socialify/backend/datasource"
+	"socialify/backend/fetch"
+	"socialify/backend/models"
+	"socialify/backend/upstream"
+	"testing"
+	"time"
+)
+
+func newYork(t *testing.T) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	return loc
+}
+
+func utc(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestBucketStart(t *testing.T) {
+	ny := newYork(t)
+	tests := []struct {
+		name     string
+		t        string
+		interval string
+		loc      *time.Location
+		want     string
+	}{
+		{"day", "2025-03-05T17:42:10Z", IntervalDay, time.UTC, "2025-03-05T00:00:00Z"},
+		{"hour", "2025-03-05T17:42:10Z", IntervalHour, time.UTC, "2025-03-05T17:00:00Z"},
+		{"week from Wednesday", "2025-03-05T17:42:10Z", IntervalWeek, time.UTC, "2025-03-03T00:00:00Z"},
+		{"week from Monday midnight", "2025-03-03T00:00:00Z", IntervalWeek, time.UTC, "2025-03-03T00:00:00Z"},
+		{"week from Sunday night", "2025-03-09T23:59:59Z", IntervalWeek, time.UTC, "2025-03-03T00:00:00Z"},
+		{"week across a month", "2025-04-02T08:00:00Z", IntervalWeek, time.UTC, "2025-03-31T00:00:00Z"},
+		{"day in another zone", "2025-03-05T03:00:00Z", IntervalDay, ny, "2025-03-04T05:00:00Z"},
+		{"day after clocks go forward", "2025-03-09T12:00:00Z", IntervalDay, ny, "2025-03-09T05:00:00Z"},
+		{"day after clocks go back", "2025-11-02T12:00:00Z", IntervalDay, ny, "2025-11-02T04:00:00Z"},
+		{"week in another zone", "2025-03-10T02:00:00Z", IntervalWeek, ny, "2025-03-03T05:00:00Z"},
+		{"first 1:30 when clocks go back", "2025-11-02T05:30:00Z", IntervalHour, ny, "2025-11-02T05:00:00Z"},
+		{"second 1:30 when clocks go back", "2025-11-02T06:30:00Z", IntervalHour, ny, "2025-11-02T06:00:00Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := bucketStart(utc(tt.t), tt.interval, tt.loc)
+			if !got.Equal(utc(tt.want)) {
+				t.Errorf("got %v, want %v", got.UTC(), tt.want)
+			}
+			if got.Location() != tt.loc {
+				t.Errorf("in %v, want %v", got.Location(), tt.loc)
+			}
+		})
+	}
+}
+
+// activityService serves two users. User 1 wrote post 1 and commented once
+// on post 2; user 2 wrote post 2 and commented twice on post 1 and once on
+// post 2.
+func activityService() *Service {
+	src := datasource.NewMemory(
+		map[string]string{"1": "Alice", "2": "Bob"},
+		[]models.Post{
+			{ID: 1, UserID: "1", Content: "one", CreatedAt: utc("2025-03-01T10:00:00Z")},
+			{ID: 2, UserID: "2", Content: "two", CreatedAt: utc("2025-03-04T10:00:00Z")},
+		},
+		[]models.Comment{
+			{ID: 10, PostID: 1, UserID: "2", Content: "a", CreatedAt: utc("2025-03-01T11:00:00Z")},
+			{ID: 11, PostID: 1, UserID: "2", Content: "b", CreatedAt: utc("2025-03-02T11:00:00Z")},
+			{ID: 12, PostID: 2, UserID: "1", Content: "c", CreatedAt: utc("2025-03-04T11:00:00Z")},
+			{ID: 13, PostID: 2, UserID: "2", Content: "d", CreatedAt: utc("2025-03-04T12:00:00Z")},
+		},
+	)
+	return New(fetch.New(src, 4))
+}
+
+func TestActivity(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  url.Values
+		counts []int
+		first  string
+	}{
+		{"empty days are zero", url.Values{}, []int{1, 0, 0, 1}, "2025-03-01T00:00:00Z"},
+		{"comments", url.Values{"metric": {"comments"}}, []int{1, 1, 0, 2}, "2025-03-01T00:00:00Z"},
+		{"window pads both ends", url.Values{"since": {"2025-02-28"}, "until": {"2025-03-06"}}, []int{0, 1, 0, 0, 1, 0}, "2025-02-28T00:00:00Z"},
+		{"weeks", url.Values{"interval": {"week"}}, []int{1, 1}, "2025-02-24T00:00:00Z"},
+		{"user's posts", url.Values{"userId": {"2"}}, []int{1}, "2025-03-04T00:00:00Z"},
+		{"comments by a user", url.Values{"userId": {"1"}, "metric": {"comments"}}, []int{1}, "2025-03-04T00:00:00Z"},
+		{"comments by a user, explicitly", url.Values{"userId": {"2"}, "metric": {"comments"}, "role": {"commenter"}}, []int{1, 1, 0, 1}, "2025-03-01T00:00:00Z"},
+		{"comments on a user's posts", url.Values{"userId": {"1"}, "metric": {"comments"}, "role": {"author"}}, []int{1, 1}, "2025-03-01T00:00:00Z"},
+		{"comments on another user's posts", url.Values{"userId": {"2"}, "metric": {"comments"}, "role": {"author"}}, []int{2}, "2025-03-04T00:00:00Z"},
+		{"nothing in the window", url.Values{"since": {"2030-01-01"}}, []int{}, ""},
+	}
+	s := activityService()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := ParseActivityQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := s.Activity(context.Background(), q)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			counts := make([]int, 0, len(res.Buckets))
+			total := 0
+			for _, b := range res.Buckets {
+				counts = append(counts, b.Count)
+				total += b.Count
+			}
+			if len(counts) != len(tt.counts) {
+				t.Fatalf("counts = %v, want %v", counts, tt.counts)
+			}
+			for i := range counts {
+				if counts[i] != tt.counts[i] {
+					t.Fatalf("counts = %v, want %v", counts, tt.counts)
+				}
+			}
+			if res.Total != total {
+				t.Errorf("total = %d, buckets add up to %d", res.Total, total)
+			}
+			if len(res.Buckets) > 0 && !res.Buckets[0].Start.Equal(utc(tt.first)) {
+				t.Errorf("first bucket starts %v, want %s", res.Buckets[0].Start, tt.first)
+			}
+		})
+	}
+}
+
+func TestActivityAcrossDST(t *testing.T) {
+	newYork(t)
+	q, err := ParseActivityQuery(url.Values{"tz": {"America/New_York"}, "since": {"2025-03-08"}, "until": {"2025-03-11"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := activityService().Activity(context.Background(), q)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	labels := []string{"2025-03-08", "2025-03-09", "2025-03-10"}
+	if len(res.Buckets) != len(labels) {
+		t.Fatalf("%d buckets, want %d", len(res.Buckets), len(labels))
+	}
+	for i, b := range res.Buckets {
+		if b.Label != labels[i] || b.Start.Hour() != 0 {
+			t.Errorf("bucket %d: %s starting %v, want local midnight on %s", i, b.Label, b.Start, labels[i])
+		}
+	}
+	if d := res.Buckets[2].Start.Sub(res.Buckets[1].Start); d != 23*time.Hour {
+		t.Errorf("the day clocks go forward lasts %v, want 23h", d)
+	}
+	if res.Timezone != "America/New_York" {
+		t.Errorf("timezone = %q", res.Timezone)
+	}
+}
+
+func TestActivityKeepsLatestBuckets(t *testing.T) {
+	q, err := ParseActivityQuery(url.Values{"interval": {"hour"}, "since": {"2025-01-01"}, "until": {"2025-03-02"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := activityService().Activity(context.Background(), q)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res.Buckets) != maxBuckets {
+		t.Fatalf("%d buckets, want %d", len(res.Buckets), maxBuckets)
+	}
+	last := utc("2025-03-01T23:00:00Z")
+	if got := res.Buckets[len(res.Buckets)-1].Start; !got.Equal(last) {
+		t.Errorf("last bucket starts %v, want %v", got, last)
+	}
+	if got, want := res.Buckets[0].Start, last.Add(-(maxBuckets-1)*time.Hour); !got.Equal(want) {
+		t.Errorf("first bucket starts %v, want %v", got, want)
+	}
+	if res.Total != 1 {
+		t.Errorf("total = %d, want the 1 post in the kept buckets", res.Total)
+	}
+}
+
+func TestActivityUnknownCommenter(t *testing.T) {
+	q, err := ParseActivityQuery(url.Values{"userId": {"9"}, "metric": {"comments"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := activityService().Activity(context.Background(), q); !errors.Is(err, upstream.ErrNotFound) {
+		t.Errorf("got %v, want a not found error", err)
+	}
+}
+
+func TestParseActivityQueryRole(t *testing.T) {
+	tests := []struct {
+		query url.Values
+		role  string
+		err   bool
+	}{
+		{url.Values{"userId": {"1"}, "metric": {"comments"}}, RoleCommenter, false},
+		{url.Values{"userId": {"1"}, "metric": {"comments"}, "role": {"author"}}, RoleAuthor, false},
+		{url.Values{"userId": {"1"}}, "", false},
+		{url.Values{"metric": {"comments"}}, "", false},
+		{url.Values{"userId": {"1"}, "metric": {"comments"}, "role": {"reader"}}, "", true},
+		{url.Values{"userId": {"1"}, "role": {"author"}}, "", true},
+		{url.Values{"metric": {"comments"}, "role": {"commenter"}}, "", true},
+	}
+	for _, tt := range tests {
+		q, err := ParseActivityQuery(tt.query)
+		if (err != nil) != tt.err {
+			t.Errorf("%v: error %v, want error %v", tt.query, err, tt.err)
+			continue
+		}
+		if q.Role != tt.role {
+			t.Errorf("%v: role %q, want %q", tt.query, q.Role, tt.role)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package analytics
+
+import (
+	"context"
+	"net/url"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"socialify/backend/datasource"
+	"socialify/backend/fetch"
+	"socialify/backend/models"
+	"testing"
+	"time"
+)
+
+// testDB builds a database with testdata/generate_testdb.go and opens it.
+func testDB(t *testing.T) *datasource.SQLite {
+	t.Helper()
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is needed to run the test database generator")
+	}
+	path := filepath.Join(t.TempDir(), "socialify_test.db")
+	cmd := exec.Command("go", "run", "generate_testdb.go", path)
+	cmd.Dir = filepath.Join("..", "..", "testdata")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("generating the test database: %v\n%s", err, out)
+	}
+
+	db, err := datasource.NewSQLite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// copyToMemory reads everything in src into a Memory, which has no
+// aggregates of its own.
+func copyToMemory(t *testing.T, src datasource.DataSource) *datasource.Memory {
+	t.Helper()
+	ctx := context.Background()
+	users, err := src.ListUsers(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make(map[string]string, len(users))
+	var posts []models.Post
+	var comments []models.Comment
+	for _, user := range users {
+		names[user.ID] = user.Name
+		userPosts, err := src.ListPostsByUser(ctx, user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		posts = append(posts, userPosts...)
+		for _, post := range userPosts {
+			postComments, err := src.ListCommentsByPost(ctx, post.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			comments = append(comments, postComments...)
+		}
+	}
+	return datasource.NewMemory(names, posts, comments)
+}
+
+func TestSQLiteAggregatesMatchMemory(t *testing.T) {
+	db := testDB(t)
+	sqlite := New(fetch.New(db, 4))
+	memory := New(fetch.New(copyToMemory(t, db), 4))
+	ctx := context.Background()
+
+	at := func(s string) time.Time {
+		v, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	windows := []datasource.Window{
+		{},
+		{Since: at("2025-03-05T00:00:00Z")},
+		{Until: at("2025-03-05T00:00:00Z")},
+		// Bounds on post creation times: post 370 is in, post 344 is out.
+		{Since: at("2025-03-07T10:00:00Z"), Until: at("2025-03-11T08:30:00Z")},
+		{Since: at("2030-01-01T00:00:00Z")},
+	}
+
+	for _, v := range []url.Values{
+		{},
+		{"limit": {"100"}},
+		{"limit": {"4"}, "offset": {"2"}},
+		{"limit": {"10"}, "ranking": {"dense"}},
+	} {
+		q, err := ParseTopUsersQuery(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := sqlite.TopUsers(ctx, q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := memory.TopUsers(ctx, q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got.TopUsers, want.TopUsers) {
+			t.Errorf("top users %v:\n got %+v\nwant %+v", v, got.TopUsers, want.TopUsers)
+		}
+	}
+
+	for _, w := range windows {
+		got, err := sqlite.LatestPosts(ctx, w)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := memory.LatestPosts(ctx, w)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got.LatestPosts, want.LatestPosts) {
+			t.Errorf("latest posts %s:\n got %+v\nwant %+v", w, got.LatestPosts, want.LatestPosts)
+		}
+	}
+
+	for _, v := range []url.Values{
+		{},
+		{"limit": {"100"}},
+		{"limit": {"5"}, "offset": {"3"}},
+		{"minComments": {"1"}},
+		{"mode": {"ties"}},
+		{"limit": {"100"}, "ranking": {"dense"}},
+	} {
+		for _, w := range windows {
+			q, err := ParsePopularQuery(v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			q.Window = w
+			got, err := sqlite.PopularPosts(ctx, q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := memory.PopularPosts(ctx, q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got.PopularPosts, want.PopularPosts) {
+				t.Errorf("popular posts %v %s:\n got %+v\nwant %+v", v, w, got.PopularPosts, want.PopularPosts)
+			}
+		}
+	}
+}
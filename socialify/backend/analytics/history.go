@@ -0,0 +1,168 @@
+package analytics
+
+import (
+	"context"
+	"log"
+	"socialify/backend/history"
+	"socialify/backend/models"
+	"socialify/backend/upstream"
+	"strconv"
+	"time"
+)
+
+// Rank movements since the last leaderboard snapshot.
+const (
+	MovementUp        = "up"
+	MovementDown      = "down"
+	MovementNew       = "new"
+	MovementUnchanged = "unchanged"
+)
+
+// boardPosts names the popular posts leaderboard in the history. The user
+// leaderboards are named by userBoard.
+const boardPosts = "posts"
+
+func userBoard(metric string) string {
+	return "users:" + metric
+}
+
+// RecordHistory snapshots the leaderboards immediately and then every
+// interval until ctx is done. Failed snapshots are logged. s.History must
+// be set.
+func (s *Service) RecordHistory(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		snapCtx, cancel := context.WithTimeout(ctx, interval)
+		if err := s.TakeSnapshots(snapCtx, time.Now().UTC().Truncate(time.Second)); err != nil && ctx.Err() == nil {
+			log.Printf("history: snapshot failed: %v", err)
+		}
+		cancel()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// TakeSnapshots records the first MaxLimit entries of the top users
+// leaderboard for every metric and of the popular posts leaderboard. A
+// leaderboard that cannot be computed is skipped and the first such error
+// returned.
+func (s *Service) TakeSnapshots(ctx context.Context, now time.Time) error {
+	page := Page{Limit: MaxLimit, Ranking: RankCompetition}
+	var firstErr error
+	record := func(board string, entries []history.Entry, err error) {
+		if err == nil {
+			err = s.History.Add(history.Snapshot{Board: board, TakenAt: now, Entries: entries})
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	for _, metric := range []string{MetricPosts, MetricComments, MetricEngagement, MetricComposite} {
+		res, err := s.topUsers(ctx, TopUsersQuery{Page: page, Metric: metric})
+		entries := make([]history.Entry, 0, len(res.TopUsers))
+		for _, upc := range res.TopUsers {
+			entries = append(entries, history.Entry{ID: upc.User.ID, Score: userScore(upc)})
+		}
+		record(userBoard(metric), entries, err)
+	}
+
+	res, err := s.popularPosts(ctx, PopularQuery{Page: page, Mode: PopularTop})
+	entries := make([]history.Entry, 0, len(res.PopularPosts))
+	for _, rp := range res.PopularPosts {
+		entries = append(entries, history.Entry{ID: strconv.Itoa(rp.Post.ID), Score: float64(rp.CommentCount)})
+	}
+	record(boardPosts, entries, err)
+
+	return firstErr
+}
+
+// previousRanks numbers the entries of the latest snapshot of board with
+// ranking and returns them by ID, with the time of the snapshot. It returns
+// nil if there is no snapshot.
+func (s *Service) previousRanks(board, ranking string) (map[string]int, *time.Time) {
+	if s.History == nil {
+		return nil, nil
+	}
+	snap, ok := s.History.Latest(board)
+	if !ok {
+		return nil, nil
+	}
+	return snapshotRanks(snap, ranking), &snap.TakenAt
+}
+
+func snapshotRanks(snap history.Snapshot, ranking string) map[string]int {
+	r := ranks(len(snap.Entries), func(i int) bool {
+		return snap.Entries[i].Score == snap.Entries[i-1].Score
+	}, ranking)
+
+	byID := make(map[string]int, len(snap.Entries))
+	for i, e := range snap.Entries {
+		byID[e.ID] = r[i]
+	}
+	return byID
+}
+
+// movement compares rank with the previous rank, 0 if there was none.
+func movement(rank, previous int) string {
+	switch {
+	case previous == 0:
+		return MovementNew
+	case rank < previous:
+		return MovementUp
+	case rank > previous:
+		return MovementDown
+	default:
+		return MovementUnchanged
+	}
+}
+
+type RankHistoryResult struct {
+	Board   string             `json:"board"`
+	ID      string             `json:"id"`
+	Ranking string             `json:"ranking"`
+	History []models.RankPoint `json:"history"`
+}
+
+// UserRankHistory returns a user's place in every snapshot of the top
+// users leaderboard for q.Metric, oldest first.
+func (s *Service) UserRankHistory(userID string, q HistoryQuery) (RankHistoryResult, error) {
+	return s.rankHistory(userBoard(q.Metric), userID, q.Ranking, "user")
+}
+
+// PostRankHistory returns a post's place in every snapshot of the popular
+// posts leaderboard, oldest first.
+func (s *Service) PostRankHistory(postID int, ranking string) (RankHistoryResult, error) {
+	return s.rankHistory(boardPosts, strconv.Itoa(postID), ranking, "post")
+}
+
+func (s *Service) rankHistory(board, id, ranking, kind string) (RankHistoryResult, error) {
+	if s.History == nil {
+		return RankHistoryResult{}, upstream.NotFound("rank history")
+	}
+
+	res := RankHistoryResult{Board: board, ID: id, Ranking: ranking, History: make([]models.RankPoint, 0)}
+	seen := false
+	for _, snap := range s.History.Snapshots(board) {
+		point := models.RankPoint{TakenAt: snap.TakenAt}
+		for _, e := range snap.Entries {
+			if e.ID == id {
+				point.Rank = snapshotRanks(snap, ranking)[id]
+				point.Score = e.Score
+				seen = true
+				break
+			}
+		}
+		res.History = append(res.History, point)
+	}
+	if !seen {
+		return RankHistoryResult{}, upstream.NotFound("rank history of " + kind + " " + id)
+	}
+	return res, nil
+}
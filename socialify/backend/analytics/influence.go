@@ -0,0 +1,100 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"socialify/backend/datasource"
+	"socialify/backend/ingest"
+	"socialify/backend/models"
+	"sort"
+)
+
+// scoreDecimals is how many decimal places influence scores are reported
+// with. PageRank stops iterating before the later ones settle.
+const scoreDecimals = 10
+
+// pull replaces the interaction network with the one of p and computes its
+// influence scores. The iteration starts from the previous scores, so a
+// refresh that changed little is cheap.
+func (s *Service) pull(p ingest.Pull) {
+	n := newNetwork(p.Users, p.Posts, p.Comments)
+
+	s.mu.RLock()
+	var previous map[string]float64
+	if s.pulled != nil {
+		previous = s.pulled.influence
+	}
+	s.mu.RUnlock()
+	n.influence, n.iterations = n.graph.PageRank(DefaultDamping, previous)
+
+	s.mu.Lock()
+	s.pulled = n
+	s.mu.Unlock()
+}
+
+type InfluentialUsersResult struct {
+	InfluentialUsers []models.InfluentialUser `json:"influentialUsers"`
+	Damping          float64                  `json:"damping"`
+	// Iterations is how many PageRank iterations the scores took.
+	Iterations int    `json:"iterations"`
+	Ranking    string `json:"ranking"`
+	Meta
+}
+
+// InfluentialUsers ranks users by their PageRank in the interaction graph,
+// ties by ID. Scores for DefaultDamping come from the background worker
+// when there is one; other damping factors are computed on request.
+func (s *Service) InfluentialUsers(ctx context.Context, q InfluenceQuery) (InfluentialUsersResult, error) {
+	key := fmt.Sprintf("influentialUsers:%g:%d:%d:%s", q.Damping, q.Limit, q.Offset, q.Ranking)
+	return remember(s, key, func() (InfluentialUsersResult, error) {
+		return s.influentialUsers(ctx, q)
+	})
+}
+
+func (s *Service) influentialUsers(ctx context.Context, q InfluenceQuery) (InfluentialUsersResult, error) {
+	n, meta, err := s.interactions(ctx)
+	if err != nil {
+		return InfluentialUsersResult{}, err
+	}
+
+	scores, iterations := n.influence, n.iterations
+	if scores == nil || q.Damping != DefaultDamping {
+		scores, iterations = n.graph.PageRank(q.Damping, n.influence)
+	}
+
+	sorted := make([]models.InfluentialUser, 0, len(scores))
+	for id, score := range scores {
+		sorted = append(sorted, models.InfluentialUser{User: n.user(id), Score: round(score, scoreDecimals)})
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Score != sorted[j].Score {
+			return sorted[i].Score > sorted[j].Score
+		}
+		return datasource.LessUserID(sorted[i].User.ID, sorted[j].User.ID)
+	})
+
+	sorted = head(sorted, q.end())
+	r := ranks(len(sorted), func(i int) bool {
+		return sorted[i].Score == sorted[i-1].Score
+	}, q.Ranking)
+
+	res := InfluentialUsersResult{
+		InfluentialUsers: make([]models.InfluentialUser, 0, q.Limit),
+		Damping:          q.Damping,
+		Iterations:       iterations,
+		Ranking:          q.Ranking,
+		Meta:             meta,
+	}
+	for i, iu := range window(sorted, q.Page) {
+		iu.Rank = r[q.Offset+i]
+		res.InfluentialUsers = append(res.InfluentialUsers, iu)
+	}
+	return res, nil
+}
+
+// round rounds x to the given number of decimal places.
+func round(x float64, decimals int) float64 {
+	scale := math.Pow(10, float64(decimals))
+	return math.Round(x*scale) / scale
+}
@@ -0,0 +1,228 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"socialify/backend/datasource"
+	"socialify/backend/graph"
+	"socialify/backend/models"
+	"socialify/backend/upstream"
+	"sort"
+)
+
+// network is the interaction graph of one read of the data source, with
+// the users it was built from.
+type network struct {
+	graph *graph.Graph
+	users map[string]models.User
+	// influence is the PageRank of every user with DefaultDamping and
+	// iterations the number of iterations it took. Only networks the
+	// worker pulled have it.
+	influence  map[string]float64
+	iterations int
+}
+
+func newNetwork(users []models.User, posts []models.Post, comments []models.Comment) *network {
+	n := &network{graph: graph.Build(users, posts, comments), users: make(map[string]models.User, len(users))}
+	for _, user := range users {
+		n.users[user.ID] = user
+	}
+	return n
+}
+
+// user returns the user with id, or a user with only the ID if the
+// commenter is not a known user.
+func (n *network) user(id string) models.User {
+	if user, ok := n.users[id]; ok {
+		return user
+	}
+	return models.User{ID: id}
+}
+
+// interactions returns the network of the worker's latest pull, or builds
+// one from a fresh read of the data source.
+func (s *Service) interactions(ctx context.Context) (*network, Meta, error) {
+	s.mu.RLock()
+	pulled := s.pulled
+	s.mu.RUnlock()
+	if pulled != nil {
+		var meta Meta
+		if snap := s.snapshot(); snap != nil {
+			meta = fromSnapshot(snap)
+		}
+		return pulled, meta, nil
+	}
+
+	byID, posts, err := s.posts(ctx)
+	if err != nil {
+		return nil, Meta{}, err
+	}
+
+	commentsByPost, err := s.Fetcher.CommentsByPost(ctx, posts)
+	if err != nil {
+		return nil, Meta{}, err
+	}
+
+	users := make([]models.User, 0, len(byID))
+	for _, user := range byID {
+		users = append(users, user)
+	}
+	comments := make([]models.Comment, 0)
+	for _, postComments := range commentsByPost {
+		comments = append(comments, postComments...)
+	}
+	return newNetwork(users, posts, comments), Meta{}, nil
+}
+
+type UserInteractionsResult struct {
+	User models.User `json:"user"`
+	// CommentsGiven and CommentsReceived leave out comments on one's own
+	// posts.
+	CommentsGiven    int `json:"commentsGiven"`
+	CommentsReceived int `json:"commentsReceived"`
+	// Reciprocity is the fraction of the users this user commented on
+	// who commented back.
+	Reciprocity float64             `json:"reciprocity"`
+	Interactors []models.Interactor `json:"interactors"`
+	Ranking     string              `json:"ranking"`
+	Meta
+}
+
+// UserInteractions ranks the users a user has exchanged comments with by
+// how many comments went either way, ties by ID.
+func (s *Service) UserInteractions(ctx context.Context, userID string, page Page) (UserInteractionsResult, error) {
+	key := fmt.Sprintf("userInteractions:%s:%d:%d:%s", userID, page.Limit, page.Offset, page.Ranking)
+	return remember(s, key, func() (UserInteractionsResult, error) {
+		return s.userInteractions(ctx, userID, page)
+	})
+}
+
+func (s *Service) userInteractions(ctx context.Context, userID string, page Page) (UserInteractionsResult, error) {
+	n, meta, err := s.interactions(ctx)
+	if err != nil {
+		return UserInteractionsResult{}, err
+	}
+	if _, ok := n.users[userID]; !ok {
+		return UserInteractionsResult{}, upstream.NotFound("user " + userID)
+	}
+
+	res := UserInteractionsResult{
+		User:        n.user(userID),
+		Reciprocity: n.graph.Reciprocity(userID),
+		Ranking:     page.Ranking,
+		Meta:        meta,
+	}
+
+	byID := make(map[string]*models.Interactor)
+	interactor := func(id string) *models.Interactor {
+		if byID[id] == nil {
+			byID[id] = &models.Interactor{User: n.user(id)}
+		}
+		return byID[id]
+	}
+	for id, weight := range n.graph.Out(userID) {
+		interactor(id).Given = weight
+		res.CommentsGiven += weight
+	}
+	for id, weight := range n.graph.In(userID) {
+		interactor(id).Received = weight
+		res.CommentsReceived += weight
+	}
+
+	sorted := make([]models.Interactor, 0, len(byID))
+	for _, in := range byID {
+		in.Total = in.Given + in.Received
+		in.Mutual = in.Given > 0 && in.Received > 0
+		sorted = append(sorted, *in)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Total != sorted[j].Total {
+			return sorted[i].Total > sorted[j].Total
+		}
+		return datasource.LessUserID(sorted[i].User.ID, sorted[j].User.ID)
+	})
+
+	sorted = head(sorted, page.end())
+	r := ranks(len(sorted), func(i int) bool {
+		return sorted[i].Total == sorted[i-1].Total
+	}, page.Ranking)
+
+	res.Interactors = make([]models.Interactor, 0, page.Limit)
+	for i, in := range window(sorted, page) {
+		in.Rank = r[page.Offset+i]
+		res.Interactors = append(res.Interactors, in)
+	}
+	return res, nil
+}
+
+type MutualPairsResult struct {
+	Pairs []models.MutualPair `json:"pairs"`
+	// Reciprocity is the fraction of all commenter to author links that
+	// go both ways.
+	Reciprocity float64 `json:"reciprocity"`
+	Ranking     string  `json:"ranking"`
+	Meta
+}
+
+// MutualPairs ranks the pairs of users who have commented on each other's
+// posts by the smaller of their two comment counts, then by the total,
+// then by ID.
+func (s *Service) MutualPairs(ctx context.Context, page Page) (MutualPairsResult, error) {
+	key := fmt.Sprintf("mutualPairs:%d:%d:%s", page.Limit, page.Offset, page.Ranking)
+	return remember(s, key, func() (MutualPairsResult, error) {
+		return s.mutualPairs(ctx, page)
+	})
+}
+
+func (s *Service) mutualPairs(ctx context.Context, page Page) (MutualPairsResult, error) {
+	n, meta, err := s.interactions(ctx)
+	if err != nil {
+		return MutualPairsResult{}, err
+	}
+
+	pairs := make([]models.MutualPair, 0)
+	for _, p := range n.graph.Mutual() {
+		if datasource.LessUserID(p.B, p.A) {
+			p.A, p.B, p.AToB, p.BToA = p.B, p.A, p.BToA, p.AToB
+		}
+		strength := p.AToB
+		if p.BToA < strength {
+			strength = p.BToA
+		}
+		pairs = append(pairs, models.MutualPair{
+			Users:    [2]models.User{n.user(p.A), n.user(p.B)},
+			Given:    [2]int{p.AToB, p.BToA},
+			Strength: strength,
+		})
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		a, b := pairs[i], pairs[j]
+		if a.Strength != b.Strength {
+			return a.Strength > b.Strength
+		}
+		if ta, tb := a.Given[0]+a.Given[1], b.Given[0]+b.Given[1]; ta != tb {
+			return ta > tb
+		}
+		if a.Users[0].ID != b.Users[0].ID {
+			return datasource.LessUserID(a.Users[0].ID, b.Users[0].ID)
+		}
+		return datasource.LessUserID(a.Users[1].ID, b.Users[1].ID)
+	})
+
+	pairs = head(pairs, page.end())
+	r := ranks(len(pairs), func(i int) bool {
+		return pairs[i].Strength == pairs[i-1].Strength
+	}, page.Ranking)
+
+	res := MutualPairsResult{
+		Pairs:       make([]models.MutualPair, 0, page.Limit),
+		Reciprocity: n.graph.OverallReciprocity(),
+		Ranking:     page.Ranking,
+		Meta:        meta,
+	}
+	for i, p := range window(pairs, page) {
+		p.Rank = r[page.Offset+i]
+		res.Pairs = append(res.Pairs, p)
+	}
+	return res, nil
+}
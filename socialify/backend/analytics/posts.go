@@ -0,0 +1,208 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"socialify/backend/datasource"
+	"socialify/backend/models"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// UserPosts returns the posts of a user created within window.
+func (s *Service) UserPosts(ctx context.Context, userID string, window datasource.Window) ([]models.Post, error) {
+	posts, err := s.Fetcher.Source.ListPostsByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return within(posts, window, func(p models.Post) time.Time { return p.CreatedAt }), nil
+}
+
+// PostComments returns the comments on a post created within window.
+func (s *Service) PostComments(ctx context.Context, postID int, window datasource.Window) ([]models.Comment, error) {
+	comments, err := s.Fetcher.Comments(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	return within(comments, window, func(c models.Comment) time.Time { return c.CreatedAt }), nil
+}
+
+type LatestPostsResult struct {
+	LatestPosts []models.PostWithUser `json:"latestPosts"`
+	Meta
+}
+
+// LatestPosts returns the newest posts created within window, in
+// datasource.NewerPost order.
+func (s *Service) LatestPosts(ctx context.Context, window datasource.Window) (LatestPostsResult, error) {
+	return remember(s, "latestPosts:"+window.String(), func() (LatestPostsResult, error) {
+		return s.latestPosts(ctx, window)
+	})
+}
+
+func (s *Service) latestPosts(ctx context.Context, window datasource.Window) (LatestPostsResult, error) {
+	if agg, ok := s.Fetcher.Source.(datasource.Aggregator); ok {
+		latestPosts, err := agg.LatestPosts(ctx, DefaultLimit, window)
+		return LatestPostsResult{LatestPosts: latestPosts}, err
+	}
+
+	if snap := s.snapshot(); snap != nil {
+		latestPosts := within(snap.LatestPosts, window, func(pu models.PostWithUser) time.Time { return pu.Post.CreatedAt })
+		return LatestPostsResult{LatestPosts: head(latestPosts, DefaultLimit), Meta: fromSnapshot(snap)}, nil
+	}
+
+	users, allPosts, err := s.posts(ctx)
+	if err != nil {
+		return LatestPostsResult{}, err
+	}
+
+	allPosts = within(allPosts, window, func(p models.Post) time.Time { return p.CreatedAt })
+	sort.Slice(allPosts, func(i, j int) bool {
+		return datasource.NewerPost(allPosts[i], allPosts[j])
+	})
+
+	latestPosts := make([]models.PostWithUser, 0, DefaultLimit)
+	for _, post := range head(allPosts, DefaultLimit) {
+		latestPosts = append(latestPosts, models.PostWithUser{
+			Post: post,
+			User: author(users, post),
+		})
+	}
+
+	return LatestPostsResult{LatestPosts: latestPosts}, nil
+}
+
+type PopularPostsResult struct {
+	PopularPosts []models.RankedPost `json:"popularPosts"`
+	Mode         string              `json:"mode"`
+	Ranking      string              `json:"ranking"`
+	// ComparedTo is set as in TopUsersResult. Movements are only measured
+	// without a window.
+	ComparedTo *time.Time `json:"comparedTo,omitempty"`
+	Meta
+}
+
+// PopularPosts ranks posts by comment count, ties by ID.
+func (s *Service) PopularPosts(ctx context.Context, q PopularQuery) (PopularPostsResult, error) {
+	key := fmt.Sprintf("popularPosts:%s:%d:%d:%d:%s:%s", q.Mode, q.MinComments, q.Limit, q.Offset, q.Ranking, q.Window)
+	return remember(s, key, func() (PopularPostsResult, error) {
+		return s.popularPosts(ctx, q)
+	})
+}
+
+func (s *Service) popularPosts(ctx context.Context, q PopularQuery) (PopularPostsResult, error) {
+	res := PopularPostsResult{Mode: q.Mode, Ranking: q.Ranking}
+
+	var sorted []models.PostWithUser
+	if agg, ok := s.Fetcher.Source.(datasource.Aggregator); ok {
+		var err error
+		if sorted, err = agg.TopPostsByCommentCount(ctx, q.end(), q.MinComments, q.Window); err != nil {
+			return PopularPostsResult{}, err
+		}
+	} else if snap := s.snapshot(); snap != nil {
+		sorted = within(snap.MostCommented, q.Window, func(pu models.PostWithUser) time.Time { return pu.Post.CreatedAt })
+		res.Meta = fromSnapshot(snap)
+	} else {
+		var err error
+		if sorted, err = s.mostCommented(ctx, q.Window); err != nil {
+			return PopularPostsResult{}, err
+		}
+	}
+
+	// Every source sorts by comment count, so the filters cut a prefix.
+	sorted = sorted[:sort.Search(len(sorted), func(i int) bool {
+		return sorted[i].CommentCount < q.MinComments
+	})]
+	if q.Mode == PopularTies && len(sorted) > 0 {
+		sorted = sorted[:sort.Search(len(sorted), func(i int) bool {
+			return sorted[i].CommentCount != sorted[0].CommentCount
+		})]
+	}
+
+	sorted = head(sorted, q.end())
+	r := ranks(len(sorted), func(i int) bool {
+		return sorted[i].CommentCount == sorted[i-1].CommentCount
+	}, q.Ranking)
+
+	page := window(sorted, q.Page)
+	res.PopularPosts = make([]models.RankedPost, 0, len(page))
+	for i, pu := range page {
+		res.PopularPosts = append(res.PopularPosts, models.RankedPost{
+			Post:         pu.Post,
+			User:         pu.User,
+			CommentCount: pu.CommentCount,
+			Rank:         r[q.Offset+i],
+		})
+	}
+
+	if !q.Window.IsZero() {
+		return res, nil
+	}
+	if prev, at := s.previousRanks(boardPosts, q.Ranking); prev != nil {
+		res.ComparedTo = at
+		for i, rp := range res.PopularPosts {
+			previous := prev[strconv.Itoa(rp.Post.ID)]
+			res.PopularPosts[i].PreviousRank = previous
+			res.PopularPosts[i].Movement = movement(rp.Rank, previous)
+		}
+	}
+	return res, nil
+}
+
+// mostCommented fetches every post created within window with its comment
+// count, most commented first and ties by ID.
+func (s *Service) mostCommented(ctx context.Context, window datasource.Window) ([]models.PostWithUser, error) {
+	users, allPosts, err := s.posts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	allPosts = within(allPosts, window, func(p models.Post) time.Time { return p.CreatedAt })
+
+	postCommentCounts, err := s.Fetcher.CommentCounts(ctx, allPosts)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(postCommentCounts, func(i, j int) bool {
+		a, b := postCommentCounts[i], postCommentCounts[j]
+		if a.CommentCount != b.CommentCount {
+			return a.CommentCount > b.CommentCount
+		}
+		return a.Post.ID < b.Post.ID
+	})
+
+	sorted := make([]models.PostWithUser, 0, len(postCommentCounts))
+	for _, pc := range postCommentCounts {
+		sorted = append(sorted, models.PostWithUser{
+			Post:         pc.Post,
+			User:         author(users, pc.Post),
+			CommentCount: pc.CommentCount,
+		})
+	}
+	return sorted, nil
+}
+
+// author returns the user who wrote post, falling back to a user with only
+// an ID if the author is unknown.
+func author(users map[string]models.User, post models.Post) models.User {
+	if user, ok := users[post.UserID]; ok {
+		return user
+	}
+	return models.User{ID: post.UserID}
+}
+
+// within returns the elements of s created within window, in order. s is
+// returned as is if the window is open on both sides.
+func within[T any](s []T, window datasource.Window, createdAt func(T) time.Time) []T {
+	if window.IsZero() {
+		return s
+	}
+	in := make([]T, 0, len(s))
+	for _, v := range s {
+		if window.Contains(createdAt(v)) {
+			in = append(in, v)
+		}
+	}
+	return in
+}
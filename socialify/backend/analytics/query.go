@@ -0,0 +1,473 @@
+package analytics
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"socialify/backend/datasource"
+	"socialify/backend/search"
+	"socialify/backend/sentiment"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Page sizes for the leaderboards.
+const (
+	DefaultLimit = 5
+	MaxLimit     = 100
+)
+
+// Ranking styles, i.e. how tied entries are numbered.
+const (
+	// RankCompetition skips ranks after a tie: 1, 2, 2, 4.
+	RankCompetition = "competition"
+	// RankDense does not: 1, 2, 2, 3.
+	RankDense = "dense"
+)
+
+// User leaderboard metrics.
+const (
+	MetricPosts      = "posts"
+	MetricComments   = "comments"
+	MetricEngagement = "engagement"
+	MetricComposite  = "composite"
+)
+
+// TopUsersQuery selects a page of the user leaderboard for a metric.
+type TopUsersQuery struct {
+	Page
+	Metric string
+}
+
+// ParseTopUsersQuery reads the metric query parameter along with the page.
+func ParseTopUsersQuery(v url.Values) (TopUsersQuery, error) {
+	page, err := ParsePage(v)
+	if err != nil {
+		return TopUsersQuery{}, err
+	}
+	metric, err := parseMetric(v)
+	if err != nil {
+		return TopUsersQuery{}, err
+	}
+
+	return TopUsersQuery{Page: page, Metric: metric}, nil
+}
+
+// parseMetric reads the top users metric query parameter.
+func parseMetric(v url.Values) (string, error) {
+	switch metric := v.Get("metric"); metric {
+	case "":
+		return MetricPosts, nil
+	case MetricPosts, MetricComments, MetricEngagement, MetricComposite:
+		return metric, nil
+	default:
+		return "", fmt.Errorf("invalid metric %q: want %s, %s, %s or %s",
+			metric, MetricPosts, MetricComments, MetricEngagement, MetricComposite)
+	}
+}
+
+// Page selects a slice of a ranked leaderboard.
+type Page struct {
+	Limit   int
+	Offset  int
+	Ranking string
+}
+
+// ParsePage reads the limit, offset and ranking query parameters.
+func ParsePage(v url.Values) (Page, error) {
+	limit, err := intParam(v, "limit", DefaultLimit, 1, MaxLimit)
+	if err != nil {
+		return Page{}, err
+	}
+	offset, err := intParam(v, "offset", 0, 0, -1)
+	if err != nil {
+		return Page{}, err
+	}
+
+	ranking, err := ParseRanking(v)
+	if err != nil {
+		return Page{}, err
+	}
+
+	return Page{Limit: limit, Offset: offset, Ranking: ranking}, nil
+}
+
+// ParseRanking reads the ranking query parameter.
+func ParseRanking(v url.Values) (string, error) {
+	switch ranking := v.Get("ranking"); ranking {
+	case "":
+		return RankCompetition, nil
+	case RankCompetition, RankDense:
+		return ranking, nil
+	default:
+		return "", fmt.Errorf("invalid ranking %q: want %s or %s", ranking, RankCompetition, RankDense)
+	}
+}
+
+// intParam parses the named parameter, which must lie in [min, max]. A
+// negative max means no upper bound.
+func intParam(v url.Values, name string, def, min, max int) (int, error) {
+	s := v.Get(name)
+	if s == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < min || (max >= 0 && n > max) {
+		if max >= 0 {
+			return 0, fmt.Errorf("invalid %s %q: want an integer from %d to %d", name, s, min, max)
+		}
+		return 0, fmt.Errorf("invalid %s %q: want an integer of at least %d", name, s, min)
+	}
+	return n, nil
+}
+
+// end is the number of leading entries the page needs.
+func (p Page) end() int {
+	if p.Limit > math.MaxInt-p.Offset {
+		return math.MaxInt
+	}
+	return p.Offset + p.Limit
+}
+
+// unlimited is a page limit no result reaches.
+const unlimited = math.MaxInt32
+
+// ranks numbers n entries sorted best first. tied reports whether entry i
+// ties with entry i-1.
+func ranks(n int, tied func(i int) bool, ranking string) []int {
+	r := make([]int, n)
+	for i := range r {
+		switch {
+		case i == 0:
+			r[i] = 1
+		case tied(i):
+			r[i] = r[i-1]
+		case ranking == RankDense:
+			r[i] = r[i-1] + 1
+		default:
+			r[i] = i + 1
+		}
+	}
+	return r
+}
+
+// window returns the page's part of s.
+func window[T any](s []T, p Page) []T {
+	if p.Offset >= len(s) {
+		return s[:0]
+	}
+	return head(s[p.Offset:], p.Limit)
+}
+
+// Popular post modes.
+const (
+	// PopularTies returns only the posts tied at the highest comment count.
+	PopularTies = "ties"
+	// PopularTop returns a ranked top-K list.
+	PopularTop = "top"
+)
+
+// PopularQuery selects popular posts.
+type PopularQuery struct {
+	Page
+	Mode        string
+	MinComments int
+	Window      datasource.Window
+}
+
+// ParsePopularQuery reads the mode and minComments query parameters along
+// with the page. Without a limit, ties mode returns every tied post.
+func ParsePopularQuery(v url.Values) (PopularQuery, error) {
+	page, err := ParsePage(v)
+	if err != nil {
+		return PopularQuery{}, err
+	}
+	minComments, err := intParam(v, "minComments", 0, 0, -1)
+	if err != nil {
+		return PopularQuery{}, err
+	}
+	window, err := ParseWindow(v)
+	if err != nil {
+		return PopularQuery{}, err
+	}
+
+	mode := v.Get("mode")
+	switch mode {
+	case "":
+		mode = PopularTies
+	case PopularTies, PopularTop:
+	default:
+		return PopularQuery{}, fmt.Errorf("invalid mode %q: want %s or %s", mode, PopularTies, PopularTop)
+	}
+	if mode == PopularTies && v.Get("limit") == "" {
+		page.Limit = unlimited
+	}
+
+	return PopularQuery{Page: page, Mode: mode, MinComments: minComments, Window: window}, nil
+}
+
+// Trending defaults. The gravity matches Hacker News.
+const (
+	DefaultHalfLife = 24 * time.Hour
+	DefaultGravity  = 1.8
+)
+
+// TrendingQuery selects trending posts.
+type TrendingQuery struct {
+	Page
+	// HalfLife is how long it takes a comment's weight to halve.
+	HalfLife time.Duration
+	// Gravity is how strongly a post's own age pulls its score down.
+	Gravity float64
+	// At is the time scores are computed for. Zero means now.
+	At time.Time
+}
+
+// ParseTrendingQuery reads the halfLife, gravity and at query parameters
+// along with the page.
+func ParseTrendingQuery(v url.Values) (TrendingQuery, error) {
+	page, err := ParsePage(v)
+	if err != nil {
+		return TrendingQuery{}, err
+	}
+	q := TrendingQuery{Page: page, HalfLife: DefaultHalfLife, Gravity: DefaultGravity}
+
+	if s := v.Get("halfLife"); s != "" {
+		if q.HalfLife, err = time.ParseDuration(s); err != nil || q.HalfLife <= 0 {
+			return TrendingQuery{}, fmt.Errorf("invalid halfLife %q: want a positive duration such as 6h", s)
+		}
+	}
+	if s := v.Get("gravity"); s != "" {
+		if q.Gravity, err = strconv.ParseFloat(s, 64); err != nil || q.Gravity < 0 || q.Gravity > 10 {
+			return TrendingQuery{}, fmt.Errorf("invalid gravity %q: want a number from 0 to 10", s)
+		}
+	}
+	if q.At, err = timeParam(v, "at", time.UTC); err != nil {
+		return TrendingQuery{}, err
+	}
+	return q, nil
+}
+
+// SearchQuery selects search results.
+type SearchQuery struct {
+	Q      string
+	Type   string
+	Limit  int
+	Offset int
+}
+
+// ParseSearchQuery reads the q, type, limit and offset query parameters.
+// q is required.
+func ParseSearchQuery(v url.Values) (SearchQuery, error) {
+	q := SearchQuery{Q: strings.TrimSpace(v.Get("q")), Type: v.Get("type")}
+	if len(search.Terms(q.Q)) == 0 {
+		return SearchQuery{}, fmt.Errorf("missing q: want words to search for")
+	}
+
+	switch q.Type {
+	case "":
+		q.Type = search.KindPost
+	case search.KindPost, search.KindComment:
+	default:
+		return SearchQuery{}, fmt.Errorf("invalid type %q: want %s or %s", q.Type, search.KindPost, search.KindComment)
+	}
+
+	var err error
+	if q.Limit, err = intParam(v, "limit", 10, 1, MaxLimit); err != nil {
+		return SearchQuery{}, err
+	}
+	if q.Offset, err = intParam(v, "offset", 0, 0, -1); err != nil {
+		return SearchQuery{}, err
+	}
+	return q, nil
+}
+
+// SentimentQuery selects posts ranked by the sentiment of their comments.
+type SentimentQuery struct {
+	Page
+	// Order is sentiment.Positive for the most positively received posts
+	// first, or sentiment.Negative for the most negatively received.
+	Order string
+}
+
+// ParseSentimentQuery reads the order query parameter along with the page.
+func ParseSentimentQuery(v url.Values) (SentimentQuery, error) {
+	page, err := ParsePage(v)
+	if err != nil {
+		return SentimentQuery{}, err
+	}
+
+	order := v.Get("order")
+	switch order {
+	case "":
+		order = sentiment.Positive
+	case sentiment.Positive, sentiment.Negative:
+	default:
+		return SentimentQuery{}, fmt.Errorf("invalid order %q: want %s or %s", order, sentiment.Positive, sentiment.Negative)
+	}
+
+	return SentimentQuery{Page: page, Order: order}, nil
+}
+
+// Activity bucket sizes. Weeks start on Monday.
+const (
+	IntervalHour = "hour"
+	IntervalDay  = "day"
+	IntervalWeek = "week"
+)
+
+// Whose comments a per-user comment series counts.
+const (
+	// RoleCommenter counts the comments the user wrote.
+	RoleCommenter = "commenter"
+	// RoleAuthor counts the comments on the user's posts.
+	RoleAuthor = "author"
+)
+
+// ActivityQuery selects an activity time series.
+type ActivityQuery struct {
+	Interval string
+	// Metric is MetricPosts or MetricComments.
+	Metric string
+	// UserID, if set, restricts the series to the user's posts, or to the
+	// comments picked by Role.
+	UserID string
+	// Role is set exactly when UserID is and Metric is MetricComments.
+	Role string
+	// Location is the time zone buckets are aligned to.
+	Location *time.Location
+	Window   datasource.Window
+}
+
+// ParseActivityQuery reads the interval, metric, userId, role and tz query
+// parameters along with the window. Dates in the window are taken as
+// midnight in tz, which defaults to UTC.
+func ParseActivityQuery(v url.Values) (ActivityQuery, error) {
+	q := ActivityQuery{Interval: v.Get("interval"), Metric: v.Get("metric"), UserID: v.Get("userId"), Location: time.UTC}
+
+	switch q.Interval {
+	case "":
+		q.Interval = IntervalDay
+	case IntervalHour, IntervalDay, IntervalWeek:
+	default:
+		return ActivityQuery{}, fmt.Errorf("invalid interval %q: want %s, %s or %s", q.Interval, IntervalHour, IntervalDay, IntervalWeek)
+	}
+
+	switch q.Metric {
+	case "":
+		q.Metric = MetricPosts
+	case MetricPosts, MetricComments:
+	default:
+		return ActivityQuery{}, fmt.Errorf("invalid metric %q: want %s or %s", q.Metric, MetricPosts, MetricComments)
+	}
+
+	switch role := v.Get("role"); {
+	case role != "" && role != RoleCommenter && role != RoleAuthor:
+		return ActivityQuery{}, fmt.Errorf("invalid role %q: want %s or %s", role, RoleCommenter, RoleAuthor)
+	case q.UserID == "" || q.Metric != MetricComments:
+		if role != "" {
+			return ActivityQuery{}, fmt.Errorf("invalid role %q: only applies with userId and metric=%s", role, MetricComments)
+		}
+	case role == "":
+		q.Role = RoleCommenter
+	default:
+		q.Role = role
+	}
+
+	var err error
+	if tz := v.Get("tz"); tz != "" {
+		if q.Location, err = time.LoadLocation(tz); err != nil {
+			return ActivityQuery{}, fmt.Errorf("invalid tz %q: want an IANA time zone such as Europe/Berlin", tz)
+		}
+	}
+	if q.Window, err = parseWindow(v, q.Location); err != nil {
+		return ActivityQuery{}, err
+	}
+	return q, nil
+}
+
+// HistoryQuery selects the leaderboard and ranking of a rank history.
+type HistoryQuery struct {
+	// Metric picks the top users leaderboard. Post histories ignore it.
+	Metric  string
+	Ranking string
+}
+
+// ParseHistoryQuery reads the metric and ranking query parameters.
+func ParseHistoryQuery(v url.Values) (HistoryQuery, error) {
+	ranking, err := ParseRanking(v)
+	if err != nil {
+		return HistoryQuery{}, err
+	}
+	metric, err := parseMetric(v)
+	if err != nil {
+		return HistoryQuery{}, err
+	}
+
+	return HistoryQuery{Metric: metric, Ranking: ranking}, nil
+}
+
+// DefaultDamping is the PageRank damping factor used unless a query sets
+// one, and the one the background worker computes influence with.
+const DefaultDamping = 0.85
+
+// InfluenceQuery selects a page of users ranked by influence.
+type InfluenceQuery struct {
+	Page
+	Damping float64
+}
+
+// ParseInfluenceQuery reads the damping query parameter along with the
+// page.
+func ParseInfluenceQuery(v url.Values) (InfluenceQuery, error) {
+	page, err := ParsePage(v)
+	if err != nil {
+		return InfluenceQuery{}, err
+	}
+	q := InfluenceQuery{Page: page, Damping: DefaultDamping}
+
+	if s := v.Get("damping"); s != "" {
+		if q.Damping, err = strconv.ParseFloat(s, 64); err != nil || q.Damping <= 0 || q.Damping >= 1 {
+			return InfluenceQuery{}, fmt.Errorf("invalid damping %q: want a number between 0 and 1", s)
+		}
+	}
+	return q, nil
+}
+
+// ParseWindow reads the since and until query parameters, each an RFC 3339
+// time or a date. Since is inclusive and until exclusive.
+func ParseWindow(v url.Values) (datasource.Window, error) {
+	return parseWindow(v, time.UTC)
+}
+
+// parseWindow is ParseWindow with dates taken as midnight in loc.
+func parseWindow(v url.Values, loc *time.Location) (datasource.Window, error) {
+	var w datasource.Window
+	var err error
+	if w.Since, err = timeParam(v, "since", loc); err != nil {
+		return w, err
+	}
+	if w.Until, err = timeParam(v, "until", loc); err != nil {
+		return w, err
+	}
+	if !w.Since.IsZero() && !w.Until.IsZero() && !w.Since.Before(w.Until) {
+		return w, fmt.Errorf("invalid window: since must be before until")
+	}
+	return w, nil
+}
+
+// timeParam reads an RFC 3339 time or a date, which is taken as midnight in
+// loc.
+func timeParam(v url.Values, name string, loc *time.Location) (time.Time, error) {
+	s := v.Get(name)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid %s %q: want an RFC 3339 time or a date", name, s)
+}
@@ -0,0 +1,65 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"socialify/backend/models"
+	"socialify/backend/search"
+)
+
+type SearchResult struct {
+	Query   string             `json:"query"`
+	Type    string             `json:"type"`
+	Total   int                `json:"total"`
+	Results []models.SearchHit `json:"results"`
+	Meta
+}
+
+// Search finds the posts or comments whose content matches q.Q. With a
+// background worker attached it reads the search index the worker keeps in
+// step; otherwise it indexes a fresh read of the data source.
+func (s *Service) Search(ctx context.Context, q SearchQuery) (SearchResult, error) {
+	key := fmt.Sprintf("search:%s:%d:%d:%s", q.Type, q.Limit, q.Offset, q.Q)
+	return remember(s, key, func() (SearchResult, error) {
+		return s.search(ctx, q)
+	})
+}
+
+func (s *Service) search(ctx context.Context, q SearchQuery) (SearchResult, error) {
+	var ix *search.Index
+	var meta Meta
+	if s.SearchIndex != nil && !s.SearchIndex.SyncedAt().IsZero() {
+		ix = s.SearchIndex
+		if snap := s.snapshot(); snap != nil {
+			meta = fromSnapshot(snap)
+		}
+	} else {
+		_, posts, err := s.posts(ctx)
+		if err != nil {
+			return SearchResult{}, err
+		}
+
+		commentsByPost, err := s.Fetcher.CommentsByPost(ctx, posts)
+		if err != nil {
+			return SearchResult{}, err
+		}
+
+		comments := make([]models.Comment, 0)
+		for _, postComments := range commentsByPost {
+			comments = append(comments, postComments...)
+		}
+		ix = search.Build(posts, comments)
+	}
+
+	hits := ix.Search(q.Q, q.Type)
+	res := SearchResult{Query: q.Q, Type: q.Type, Total: len(hits), Results: make([]models.SearchHit, 0), Meta: meta}
+	for _, hit := range window(hits, Page{Limit: q.Limit, Offset: q.Offset}) {
+		res.Results = append(res.Results, models.SearchHit{
+			Post:    hit.Post,
+			Comment: hit.Comment,
+			Score:   hit.Score,
+			Snippet: hit.Snippet,
+		})
+	}
+	return res, nil
+}
@@ -0,0 +1,156 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"socialify/backend/models"
+	"socialify/backend/sentiment"
+	"sort"
+	"strconv"
+)
+
+type PostSentimentResult struct {
+	PostID    int              `json:"postId"`
+	Sentiment models.Sentiment `json:"sentiment"`
+	Meta
+}
+
+// PostSentiment sums up the sentiment of the comments on a post.
+func (s *Service) PostSentiment(ctx context.Context, postID int) (PostSentimentResult, error) {
+	return remember(s, "postSentiment:"+strconv.Itoa(postID), func() (PostSentimentResult, error) {
+		comments, err := s.Fetcher.Comments(ctx, postID)
+		if err != nil {
+			return PostSentimentResult{}, err
+		}
+		return PostSentimentResult{PostID: postID, Sentiment: summarize(comments)}, nil
+	})
+}
+
+type UserSentimentResult struct {
+	User      models.User `json:"user"`
+	PostCount int         `json:"postCount"`
+	// Sentiment covers the comments on all of the user's posts.
+	Sentiment models.Sentiment `json:"sentiment"`
+	Meta
+}
+
+// UserSentiment sums up the sentiment of the comments a user's posts
+// received.
+func (s *Service) UserSentiment(ctx context.Context, userID string) (UserSentimentResult, error) {
+	return remember(s, "userSentiment:"+userID, func() (UserSentimentResult, error) {
+		return s.userSentiment(ctx, userID)
+	})
+}
+
+func (s *Service) userSentiment(ctx context.Context, userID string) (UserSentimentResult, error) {
+	posts, err := s.Fetcher.Source.ListPostsByUser(ctx, userID)
+	if err != nil {
+		return UserSentimentResult{}, err
+	}
+
+	user, err := s.user(ctx, userID)
+	if err != nil {
+		return UserSentimentResult{}, err
+	}
+
+	res := UserSentimentResult{User: user, PostCount: len(posts)}
+
+	commentsByPost, err := s.Fetcher.CommentsByPost(ctx, posts)
+	if err != nil {
+		return UserSentimentResult{}, err
+	}
+
+	comments := make([]models.Comment, 0)
+	for _, postComments := range commentsByPost {
+		comments = append(comments, postComments...)
+	}
+	res.Sentiment = summarize(comments)
+	return res, nil
+}
+
+type SentimentPostsResult struct {
+	Posts   []models.PostSentiment `json:"posts"`
+	Order   string                 `json:"order"`
+	Ranking string                 `json:"ranking"`
+	Meta
+}
+
+// SentimentPosts ranks the posts with comments by the mean sentiment of
+// those comments, most positive or most negative first as q.Order says.
+// Posts with the same score are ordered by comment count, then ID.
+func (s *Service) SentimentPosts(ctx context.Context, q SentimentQuery) (SentimentPostsResult, error) {
+	key := fmt.Sprintf("sentimentPosts:%s:%d:%d:%s", q.Order, q.Limit, q.Offset, q.Ranking)
+	return remember(s, key, func() (SentimentPostsResult, error) {
+		return s.sentimentPosts(ctx, q)
+	})
+}
+
+func (s *Service) sentimentPosts(ctx context.Context, q SentimentQuery) (SentimentPostsResult, error) {
+	users, posts, err := s.posts(ctx)
+	if err != nil {
+		return SentimentPostsResult{}, err
+	}
+
+	commentsByPost, err := s.Fetcher.CommentsByPost(ctx, posts)
+	if err != nil {
+		return SentimentPostsResult{}, err
+	}
+
+	scored := make([]models.PostSentiment, 0)
+	for i, post := range posts {
+		if len(commentsByPost[i]) == 0 {
+			continue
+		}
+		scored = append(scored, models.PostSentiment{
+			Post:      post,
+			User:      author(users, post),
+			Sentiment: summarize(commentsByPost[i]),
+		})
+	}
+
+	sort.Slice(scored, func(i, j int) bool {
+		a, b := scored[i].Sentiment, scored[j].Sentiment
+		if a.Score != b.Score {
+			return (a.Score > b.Score) == (q.Order == sentiment.Positive)
+		}
+		if a.Comments != b.Comments {
+			return a.Comments > b.Comments
+		}
+		return scored[i].Post.ID < scored[j].Post.ID
+	})
+
+	sorted := head(scored, q.end())
+	r := ranks(len(sorted), func(i int) bool {
+		return sorted[i].Sentiment.Score == sorted[i-1].Sentiment.Score
+	}, q.Ranking)
+
+	res := SentimentPostsResult{Posts: make([]models.PostSentiment, 0, q.Limit), Order: q.Order, Ranking: q.Ranking}
+	for i, ps := range window(sorted, q.Page) {
+		ps.Rank = r[q.Offset+i]
+		res.Posts = append(res.Posts, ps)
+	}
+	return res, nil
+}
+
+// summarize counts comments by sentiment label and averages their scores.
+func summarize(comments []models.Comment) models.Sentiment {
+	sum := models.Sentiment{Comments: len(comments)}
+	if len(comments) == 0 {
+		return sum
+	}
+
+	total := 0.0
+	for _, comment := range comments {
+		switch sentiment.Label(comment.Sentiment) {
+		case sentiment.Positive:
+			sum.Positive++
+		case sentiment.Negative:
+			sum.Negative++
+		default:
+			sum.Neutral++
+		}
+		total += comment.Sentiment
+	}
+	sum.Score = total / float64(len(comments))
+	return sum
+}
@@ -0,0 +1,162 @@
+package analytics
+
+import (
+	"context"
+	"log"
+	"socialify/backend/fallback"
+	"socialify/backend/fetch"
+	"socialify/backend/history"
+	"socialify/backend/ingest"
+	"socialify/backend/models"
+	"socialify/backend/search"
+	"socialify/backend/topics"
+	"socialify/backend/upstream"
+	"sync"
+	"time"
+)
+
+// Service computes the analytics served by both API servers. The top users,
+// latest and popular posts come from the data source's own aggregates when
+// it has them, then from the background index when there is one, and
+// otherwise from a live fetch; the other results skip the aggregates. While the
+// upstream is unavailable the last good result is returned, marked stale.
+type Service struct {
+	Fetcher *fetch.Orchestrator
+	// Index, SearchIndex and TopicIndex are optional. Set them, or call
+	// Attach, before serving.
+	Index       *ingest.Index
+	SearchIndex *search.Index
+	TopicIndex  *topics.Index
+	// History holds the leaderboard snapshots that rank movement is
+	// measured against. It is optional.
+	History *history.Store
+
+	lastGood *fallback.Group
+
+	// pulled is the interaction network of the worker's latest pull.
+	mu     sync.RWMutex
+	pulled *network
+}
+
+func New(fetcher *fetch.Orchestrator) *Service {
+	return &Service{
+		Fetcher:  fetcher,
+		lastGood: fallback.NewGroup(fallback.DefaultCapacity),
+	}
+}
+
+// Attach serves the leaderboards, search, topics, interactions and
+// influence from what w pulls. Call it before w runs.
+func (s *Service) Attach(w *ingest.Worker) {
+	s.Index = w.Index
+	s.SearchIndex = search.NewIndex()
+	s.TopicIndex = topics.NewIndex()
+	w.OnRefresh = append(w.OnRefresh, func(p ingest.Pull) {
+		now := time.Now()
+		s.SearchIndex.Sync(p.Posts, p.Comments, now)
+		s.TopicIndex.Sync(p.Posts, now)
+		s.pull(p)
+	})
+}
+
+// Meta says how fresh a result is. It is empty for results computed on
+// request.
+type Meta struct {
+	*Refresh
+	*Staleness
+}
+
+// Refresh is set on results served from the background index.
+type Refresh struct {
+	RefreshedAt     time.Time `json:"refreshedAt"`
+	RefreshInterval string    `json:"refreshInterval"`
+}
+
+// Staleness is set on results that are older than they should be: a last
+// good result served during an outage, or an index snapshot that has missed
+// refreshes.
+type Staleness struct {
+	Stale      bool      `json:"stale"`
+	StaleSince time.Time `json:"staleSince"`
+	AgeSeconds int       `json:"ageSeconds"`
+}
+
+func (m *Meta) meta() *Meta {
+	return m
+}
+
+func (m *Meta) markStale(since, now time.Time) {
+	m.Staleness = &Staleness{
+		Stale:      true,
+		StaleSince: since,
+		AgeSeconds: int(now.Sub(since).Seconds()),
+	}
+}
+
+func fromSnapshot(snap *ingest.Snapshot) Meta {
+	m := Meta{Refresh: &Refresh{
+		RefreshedAt:     snap.RefreshedAt,
+		RefreshInterval: snap.RefreshInterval.String(),
+	}}
+	if now := time.Now(); snap.Stale(now) {
+		m.markStale(snap.RefreshedAt, now)
+	}
+	return m
+}
+
+// snapshot returns the latest background snapshot, or nil if ingestion is
+// off or has not completed a refresh yet.
+func (s *Service) snapshot() *ingest.Snapshot {
+	if s.Index == nil {
+		return nil
+	}
+	return s.Index.Snapshot()
+}
+
+type result[R any] interface {
+	*R
+	meta() *Meta
+}
+
+// remember returns what compute returns and keeps it under key. If compute
+// fails because the upstream is unavailable, the last good result for key is
+// returned instead. Keys start with the name of the endpoint and a ':'; each
+// endpoint keeps its own fallback.DefaultCapacity results.
+func remember[R any, P result[R]](s *Service, key string, compute func() (R, error)) (R, error) {
+	res, err := compute()
+	if err == nil {
+		s.lastGood.Remember(key, res, time.Now())
+		return res, nil
+	}
+
+	if upstream.Outage(err) {
+		if last, at, ok := s.lastGood.Last(key); ok {
+			log.Printf("%s: serving stale result: %v", key, err)
+			stale := last.(R)
+			P(&stale).meta().markStale(at, time.Now())
+			return stale, nil
+		}
+	}
+	return res, err
+}
+
+// posts fetches every user and all of their posts, keyed by user ID.
+func (s *Service) posts(ctx context.Context) (map[string]models.User, []models.Post, error) {
+	users, err := s.Fetcher.Source.ListUsers(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	postsByUser, err := s.Fetcher.PostsByUser(ctx, users)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	byID := make(map[string]models.User, len(users))
+	allPosts := make([]models.Post, 0)
+	for i, user := range users {
+		byID[user.ID] = user
+		allPosts = append(allPosts, postsByUser[i]...)
+	}
+	return byID, allPosts, nil
+}
@@ -0,0 +1,77 @@
+package analytics
+
+import (
+	"context"
+	"socialify/backend/datasource"
+	"socialify/backend/models"
+	"socialify/backend/upstream"
+)
+
+type UserStatsResult struct {
+	User               models.User `json:"user"`
+	PostCount          int         `json:"postCount"`
+	CommentsReceived   int         `json:"commentsReceived"`
+	AvgCommentsPerPost float64     `json:"avgCommentsPerPost"`
+	// MostCommentedPost and LatestPost are nil for users without posts.
+	MostCommentedPost *models.PostCommentCount `json:"mostCommentedPost"`
+	LatestPost        *models.Post             `json:"latestPost"`
+	// Rank is the user's place in the top users leaderboard.
+	Rank    int    `json:"rank"`
+	Ranking string `json:"ranking"`
+	Meta
+}
+
+// UserStats profiles one user's posts and the comments they received.
+func (s *Service) UserStats(ctx context.Context, userID, ranking string) (UserStatsResult, error) {
+	return remember(s, "userStats:"+userID+":"+ranking, func() (UserStatsResult, error) {
+		return s.userStats(ctx, userID, ranking)
+	})
+}
+
+func (s *Service) userStats(ctx context.Context, userID, ranking string) (UserStatsResult, error) {
+	posts, err := s.Fetcher.Source.ListPostsByUser(ctx, userID)
+	if err != nil {
+		return UserStatsResult{}, err
+	}
+
+	counts, err := s.Fetcher.CommentCounts(ctx, posts)
+	if err != nil {
+		return UserStatsResult{}, err
+	}
+
+	users, err := s.Fetcher.Source.ListUsers(ctx)
+	if err != nil {
+		return UserStatsResult{}, err
+	}
+
+	sorted, meta, err := s.leaderboard(ctx, len(users))
+	if err != nil {
+		return UserStatsResult{}, err
+	}
+
+	res := UserStatsResult{PostCount: len(posts), Ranking: ranking, Meta: meta}
+	for _, upc := range rankUsers(sorted, Page{Limit: len(sorted), Ranking: ranking}) {
+		if upc.User.ID == userID {
+			res.User = upc.User
+			res.Rank = upc.Rank
+		}
+	}
+	if res.User.ID == "" {
+		return UserStatsResult{}, upstream.NotFound("user " + userID)
+	}
+
+	for i, pc := range counts {
+		res.CommentsReceived += pc.CommentCount
+		if res.MostCommentedPost == nil || pc.CommentCount > res.MostCommentedPost.CommentCount ||
+			pc.CommentCount == res.MostCommentedPost.CommentCount && pc.Post.ID < res.MostCommentedPost.Post.ID {
+			res.MostCommentedPost = &counts[i]
+		}
+		if res.LatestPost == nil || datasource.NewerPost(pc.Post, *res.LatestPost) {
+			res.LatestPost = &counts[i].Post
+		}
+	}
+	if len(posts) > 0 {
+		res.AvgCommentsPerPost = float64(res.CommentsReceived) / float64(len(posts))
+	}
+	return res, nil
+}
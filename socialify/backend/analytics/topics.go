@@ -0,0 +1,171 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"socialify/backend/datasource"
+	"socialify/backend/models"
+	"socialify/backend/topics"
+	"sort"
+)
+
+type TopicsResult struct {
+	Topics  []models.TopicCount `json:"topics"`
+	Ranking string              `json:"ranking"`
+	Meta
+}
+
+// Topics ranks the topics of all posts by how many posts mention them, ties
+// by topic.
+func (s *Service) Topics(ctx context.Context, page Page) (TopicsResult, error) {
+	key := fmt.Sprintf("topics:%d:%d:%s", page.Limit, page.Offset, page.Ranking)
+	return remember(s, key, func() (TopicsResult, error) {
+		return s.topics(ctx, page)
+	})
+}
+
+func (s *Service) topics(ctx context.Context, page Page) (TopicsResult, error) {
+	ix, meta := s.topicIndex()
+	if ix == nil {
+		_, posts, err := s.posts(ctx)
+		if err != nil {
+			return TopicsResult{}, err
+		}
+		ix = topics.Build(posts)
+	}
+
+	counts := head(ix.Counts(), page.end())
+	r := ranks(len(counts), func(i int) bool {
+		return counts[i].PostCount == counts[i-1].PostCount
+	}, page.Ranking)
+
+	res := TopicsResult{Topics: make([]models.TopicCount, 0, page.Limit), Ranking: page.Ranking, Meta: meta}
+	for i, tc := range window(counts, page) {
+		res.Topics = append(res.Topics, models.TopicCount{
+			Topic:     tc.Topic,
+			PostCount: tc.PostCount,
+			UserCount: tc.UserCount,
+			Rank:      r[page.Offset+i],
+		})
+	}
+	return res, nil
+}
+
+// topicIndex returns the topic index the worker keeps in step, with the
+// freshness of the worker's latest pull, or nil if there is none yet.
+func (s *Service) topicIndex() (*topics.Index, Meta) {
+	if s.TopicIndex == nil || s.TopicIndex.SyncedAt().IsZero() {
+		return nil, Meta{}
+	}
+	var meta Meta
+	if snap := s.snapshot(); snap != nil {
+		meta = fromSnapshot(snap)
+	}
+	return s.TopicIndex, meta
+}
+
+type TopicPostsResult struct {
+	Topic string                `json:"topic"`
+	Posts []models.PostWithUser `json:"posts"`
+	Meta
+}
+
+// TopicPosts returns the posts about topic in datasource.NewerPost order.
+func (s *Service) TopicPosts(ctx context.Context, topic string) (TopicPostsResult, error) {
+	topic = topics.Normalize(topic)
+	return remember(s, "topicPosts:"+topic, func() (TopicPostsResult, error) {
+		return s.topicPosts(ctx, topic)
+	})
+}
+
+func (s *Service) topicPosts(ctx context.Context, topic string) (TopicPostsResult, error) {
+	posts, meta, err := s.everyPost(ctx)
+	if err != nil {
+		return TopicPostsResult{}, err
+	}
+
+	about := func(post models.Post) bool {
+		for _, t := range topics.Extract(post.Content) {
+			if t == topic {
+				return true
+			}
+		}
+		return false
+	}
+	if ix, _ := s.topicIndex(); ix != nil {
+		about = func(post models.Post) bool { return ix.Mentions(post.ID, topic) }
+	}
+
+	res := TopicPostsResult{Topic: topic, Posts: make([]models.PostWithUser, 0), Meta: meta}
+	for _, pu := range posts {
+		if about(pu.Post) {
+			res.Posts = append(res.Posts, pu)
+		}
+	}
+	return res, nil
+}
+
+type UserTopicsResult struct {
+	User models.User `json:"user"`
+	// Topics are ranked by post count. Share is the fraction of the user's
+	// posts about the topic.
+	Topics []models.TopicCount `json:"topics"`
+	Meta
+}
+
+// UserTopics profiles the topics a user posts about.
+func (s *Service) UserTopics(ctx context.Context, userID string) (UserTopicsResult, error) {
+	return remember(s, "userTopics:"+userID, func() (UserTopicsResult, error) {
+		return s.userTopics(ctx, userID)
+	})
+}
+
+func (s *Service) userTopics(ctx context.Context, userID string) (UserTopicsResult, error) {
+	posts, err := s.Fetcher.Source.ListPostsByUser(ctx, userID)
+	if err != nil {
+		return UserTopicsResult{}, err
+	}
+
+	user, err := s.user(ctx, userID)
+	if err != nil {
+		return UserTopicsResult{}, err
+	}
+
+	res := UserTopicsResult{User: user, Topics: make([]models.TopicCount, 0)}
+	counts := topics.Build(posts).Counts()
+	r := ranks(len(counts), func(i int) bool {
+		return counts[i].PostCount == counts[i-1].PostCount
+	}, RankCompetition)
+	for i, tc := range counts {
+		res.Topics = append(res.Topics, models.TopicCount{
+			Topic:     tc.Topic,
+			PostCount: tc.PostCount,
+			Share:     float64(tc.PostCount) / float64(len(posts)),
+			Rank:      r[i],
+		})
+	}
+	return res, nil
+}
+
+// everyPost returns every post with its author in datasource.NewerPost
+// order.
+func (s *Service) everyPost(ctx context.Context) ([]models.PostWithUser, Meta, error) {
+	if snap := s.snapshot(); snap != nil {
+		return snap.LatestPosts, fromSnapshot(snap), nil
+	}
+
+	users, allPosts, err := s.posts(ctx)
+	if err != nil {
+		return nil, Meta{}, err
+	}
+
+	sort.Slice(allPosts, func(i, j int) bool {
+		return datasource.NewerPost(allPosts[i], allPosts[j])
+	})
+
+	posts := make([]models.PostWithUser, 0, len(allPosts))
+	for _, post := range allPosts {
+		posts = append(posts, models.PostWithUser{Post: post, User: author(users, post)})
+	}
+	return posts, Meta{}, nil
+}
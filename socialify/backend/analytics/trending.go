@@ -0,0 +1,118 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"socialify/backend/models"
+	"sort"
+	"time"
+)
+
+type TrendingPostsResult struct {
+	TrendingPosts []models.TrendingPost `json:"trendingPosts"`
+	HalfLife      string                `json:"halfLife"`
+	Gravity       float64               `json:"gravity"`
+	At            time.Time             `json:"at"`
+	Ranking       string                `json:"ranking"`
+	Meta
+}
+
+// TrendingPosts ranks posts with comments by how fast they are being
+// commented on as of q.At, or now. Posts and comments created after that
+// are ignored.
+func (s *Service) TrendingPosts(ctx context.Context, q TrendingQuery) (TrendingPostsResult, error) {
+	key := fmt.Sprintf("trendingPosts:%s:%g:%d:%d:%s", q.HalfLife, q.Gravity, q.Limit, q.Offset, q.Ranking)
+	if !q.At.IsZero() {
+		// Scores move continuously with the current time, so only a
+		// fixed at can be served from the last good results.
+		key += ":" + q.At.UTC().Format(time.RFC3339Nano)
+	}
+	return remember(s, key, func() (TrendingPostsResult, error) {
+		if q.At.IsZero() {
+			q.At = time.Now()
+		}
+		return s.trendingPosts(ctx, q)
+	})
+}
+
+func (s *Service) trendingPosts(ctx context.Context, q TrendingQuery) (TrendingPostsResult, error) {
+	users, allPosts, err := s.posts(ctx)
+	if err != nil {
+		return TrendingPostsResult{}, err
+	}
+
+	posts := make([]models.Post, 0, len(allPosts))
+	for _, post := range allPosts {
+		if !post.CreatedAt.After(q.At) {
+			posts = append(posts, post)
+		}
+	}
+
+	commentsByPost, err := s.Fetcher.CommentsByPost(ctx, posts)
+	if err != nil {
+		return TrendingPostsResult{}, err
+	}
+
+	trending := make([]models.TrendingPost, 0)
+	for i, post := range posts {
+		count := 0
+		for _, comment := range commentsByPost[i] {
+			if !comment.CreatedAt.After(q.At) {
+				count++
+			}
+		}
+		if count == 0 {
+			continue
+		}
+		trending = append(trending, models.TrendingPost{
+			Post:         post,
+			User:         author(users, post),
+			CommentCount: count,
+			Score:        trendingScore(post, commentsByPost[i], q),
+		})
+	}
+
+	sort.Slice(trending, func(i, j int) bool {
+		if trending[i].Score != trending[j].Score {
+			return trending[i].Score > trending[j].Score
+		}
+		return trending[i].Post.ID < trending[j].Post.ID
+	})
+
+	sorted := head(trending, q.end())
+	r := ranks(len(sorted), func(i int) bool {
+		return sorted[i].Score == sorted[i-1].Score
+	}, q.Ranking)
+
+	res := TrendingPostsResult{
+		TrendingPosts: make([]models.TrendingPost, 0, q.Limit),
+		HalfLife:      q.HalfLife.String(),
+		Gravity:       q.Gravity,
+		At:            q.At,
+		Ranking:       q.Ranking,
+	}
+	for i, tp := range window(sorted, q.Page) {
+		tp.Rank = r[q.Offset+i]
+		res.TrendingPosts = append(res.TrendingPosts, tp)
+	}
+	return res, nil
+}
+
+// trendingScore is the comment velocity of post divided by its age in hours
+// plus two, raised to the gravity, as in Hacker News ranking. The velocity
+// is the number of comments with each one's weight halved for every half
+// life that has passed since it was made.
+func trendingScore(post models.Post, comments []models.Comment, q TrendingQuery) float64 {
+	velocity := 0.0
+	for _, comment := range comments {
+		age := q.At.Sub(comment.CreatedAt)
+		if age < 0 {
+			continue
+		}
+		velocity += math.Exp2(-float64(age) / float64(q.HalfLife))
+	}
+
+	ageHours := math.Max(q.At.Sub(post.CreatedAt).Hours(), 0)
+	return velocity / math.Pow(ageHours+2, q.Gravity)
+}
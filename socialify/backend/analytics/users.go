@@ -0,0 +1,229 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"socialify/backend/datasource"
+	"socialify/backend/models"
+	"socialify/backend/upstream"
+	"sort"
+	"time"
+)
+
+type TopUsersResult struct {
+	TopUsers []models.UserPostCount `json:"topUsers"`
+	Metric   string                 `json:"metric"`
+	Ranking  string                 `json:"ranking"`
+	// ComparedTo is when the snapshot that movements are measured against
+	// was taken.
+	ComparedTo *time.Time `json:"comparedTo,omitempty"`
+	Meta
+}
+
+// Weights of the composite metric. Each component is first scaled by the
+// highest value among all users, so the composite score lies in [0, 1].
+var (
+	CompositePostsWeight    = 0.2
+	CompositeCommentsWeight = 0.4
+	CompositeRateWeight     = 0.4
+)
+
+// TopUsers returns a page of the users ranked by q.Metric. The posts metric
+// uses datasource.LessUserPostCount order; the others rank by score, ties
+// in that order.
+func (s *Service) TopUsers(ctx context.Context, q TopUsersQuery) (TopUsersResult, error) {
+	key := fmt.Sprintf("topUsers:%s:%d:%d:%s", q.Metric, q.Limit, q.Offset, q.Ranking)
+	return remember(s, key, func() (TopUsersResult, error) {
+		return s.topUsers(ctx, q)
+	})
+}
+
+func (s *Service) topUsers(ctx context.Context, q TopUsersQuery) (TopUsersResult, error) {
+	var sorted []models.UserPostCount
+	var meta Meta
+	var err error
+	if q.Metric == MetricPosts {
+		sorted, meta, err = s.leaderboard(ctx, q.end())
+	} else {
+		sorted, meta, err = s.engagement(ctx, q.Metric)
+	}
+	if err != nil {
+		return TopUsersResult{}, err
+	}
+
+	res := TopUsersResult{TopUsers: rankUsers(sorted, q.Page), Metric: q.Metric, Ranking: q.Ranking, Meta: meta}
+	if prev, at := s.previousRanks(userBoard(q.Metric), q.Ranking); prev != nil {
+		res.ComparedTo = at
+		for i, upc := range res.TopUsers {
+			res.TopUsers[i].PreviousRank = prev[upc.User.ID]
+			res.TopUsers[i].Movement = movement(upc.Rank, prev[upc.User.ID])
+		}
+	}
+	return res, nil
+}
+
+// engagement returns every user with their engagement scored by metric,
+// best first.
+func (s *Service) engagement(ctx context.Context, metric string) ([]models.UserPostCount, Meta, error) {
+	var users []models.UserPostCount
+	var meta Meta
+	commentsReceived := make(map[string]int)
+
+	if snap := s.snapshot(); snap != nil {
+		users = append(users, snap.TopUsers...)
+		for _, pu := range snap.MostCommented {
+			commentsReceived[pu.Post.UserID] += pu.CommentCount
+		}
+		meta = fromSnapshot(snap)
+	} else {
+		list, err := s.Fetcher.Source.ListUsers(ctx)
+		if err != nil {
+			return nil, Meta{}, err
+		}
+
+		postsByUser, err := s.Fetcher.PostsByUser(ctx, list)
+		if err != nil {
+			return nil, Meta{}, err
+		}
+
+		allPosts := make([]models.Post, 0)
+		for i, user := range list {
+			users = append(users, models.UserPostCount{User: user, PostCount: len(postsByUser[i])})
+			allPosts = append(allPosts, postsByUser[i]...)
+		}
+
+		counts, err := s.Fetcher.CommentCounts(ctx, allPosts)
+		if err != nil {
+			return nil, Meta{}, err
+		}
+		for _, pc := range counts {
+			commentsReceived[pc.Post.UserID] += pc.CommentCount
+		}
+	}
+
+	var maxPosts, maxComments, maxRate float64
+	for i, upc := range users {
+		e := &models.Engagement{CommentsReceived: commentsReceived[upc.User.ID]}
+		if upc.PostCount > 0 {
+			e.CommentsPerPost = float64(e.CommentsReceived) / float64(upc.PostCount)
+		}
+		users[i].Engagement = e
+		maxPosts = math.Max(maxPosts, float64(upc.PostCount))
+		maxComments = math.Max(maxComments, float64(e.CommentsReceived))
+		maxRate = math.Max(maxRate, e.CommentsPerPost)
+	}
+
+	for _, upc := range users {
+		e := upc.Engagement
+		switch metric {
+		case MetricComments:
+			e.Score = float64(e.CommentsReceived)
+		case MetricEngagement:
+			e.Score = e.CommentsPerPost
+		case MetricComposite:
+			e.Score = CompositePostsWeight*scaled(float64(upc.PostCount), maxPosts) +
+				CompositeCommentsWeight*scaled(float64(e.CommentsReceived), maxComments) +
+				CompositeRateWeight*scaled(e.CommentsPerPost, maxRate)
+		}
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		if a, b := users[i].Engagement.Score, users[j].Engagement.Score; a != b {
+			return a > b
+		}
+		return datasource.LessUserPostCount(users[i], users[j])
+	})
+	return users, meta, nil
+}
+
+// scaled returns v as a fraction of max, or 0 if max is 0.
+func scaled(v, max float64) float64 {
+	if max == 0 {
+		return 0
+	}
+	return v / max
+}
+
+// leaderboard returns the first n users in datasource.LessUserPostCount
+// order.
+func (s *Service) leaderboard(ctx context.Context, n int) ([]models.UserPostCount, Meta, error) {
+	if agg, ok := s.Fetcher.Source.(datasource.Aggregator); ok {
+		topUsers, err := agg.TopUsersByPostCount(ctx, n)
+		return topUsers, Meta{}, err
+	}
+
+	if snap := s.snapshot(); snap != nil {
+		return head(snap.TopUsers, n), fromSnapshot(snap), nil
+	}
+
+	users, err := s.Fetcher.Source.ListUsers(ctx)
+	if err != nil {
+		return nil, Meta{}, err
+	}
+
+	postsByUser, err := s.Fetcher.PostsByUser(ctx, users)
+	if err != nil {
+		return nil, Meta{}, err
+	}
+
+	userPostCounts := make([]models.UserPostCount, 0, len(users))
+	for i, user := range users {
+		userPostCounts = append(userPostCounts, models.UserPostCount{
+			User:      user,
+			PostCount: len(postsByUser[i]),
+		})
+	}
+
+	sort.Slice(userPostCounts, func(i, j int) bool {
+		return datasource.LessUserPostCount(userPostCounts[i], userPostCounts[j])
+	})
+
+	return head(userPostCounts, n), Meta{}, nil
+}
+
+// rankUsers returns the page of sorted with ranks filled in. Users with the
+// same score, or post count if they have no engagement, share a rank.
+// sorted is not modified.
+func rankUsers(sorted []models.UserPostCount, page Page) []models.UserPostCount {
+	sorted = head(sorted, page.end())
+	r := ranks(len(sorted), func(i int) bool {
+		return userScore(sorted[i]) == userScore(sorted[i-1])
+	}, page.Ranking)
+
+	ranked := make([]models.UserPostCount, 0, page.Limit)
+	for i, upc := range window(sorted, page) {
+		upc.Rank = r[page.Offset+i]
+		ranked = append(ranked, upc)
+	}
+	return ranked
+}
+
+func userScore(upc models.UserPostCount) float64 {
+	if upc.Engagement != nil {
+		return upc.Engagement.Score
+	}
+	return float64(upc.PostCount)
+}
+
+// user looks up the user with userID.
+func (s *Service) user(ctx context.Context, userID string) (models.User, error) {
+	users, err := s.Fetcher.Source.ListUsers(ctx)
+	if err != nil {
+		return models.User{}, err
+	}
+	for _, user := range users {
+		if user.ID == userID {
+			return user, nil
+		}
+	}
+	return models.User{}, upstream.NotFound("user " + userID)
+}
+
+// head returns at most the first n elements of s.
+func head[T any](s []T, n int) []T {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
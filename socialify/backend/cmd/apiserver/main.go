@@ -2,68 +2,34 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
-	"os"
+	"socialify/backend/analytics"
+	"socialify/backend/config"
+	"socialify/backend/datasource"
+	"socialify/backend/fetch"
+	"socialify/backend/history"
+	"socialify/backend/ingest"
+	"socialify/backend/middleware"
 	"socialify/backend/models"
+	"socialify/backend/upstream"
 	"socialify/backend/utils"
 	"strconv"
+	"strings"
 	"time"
 )
 
 var (
-	clientID     string
-	clientSecret string
-	companyName  string
-	ownerName    string
-	ownerEmail   string
-	rollNo       string
+	source  datasource.DataSource
+	service *analytics.Service
 )
 
 func init() {
-	clientID = os.Getenv("CLIENT_ID")
-	clientSecret = os.Getenv("CLIENT_SECRET")
-	companyName = os.Getenv("COMPANY_NAME")
-	ownerName = os.Getenv("OWNER_NAME")
-	ownerEmail = os.Getenv("OWNER_EMAIL")
-	rollNo = os.Getenv("ROLL_NO")
-
-	// Default values for demo purposes
-	if clientID == "" {
-		clientID = "demo_client_id"
-	}
-	if clientSecret == "" {
-		clientSecret = "demo_client_secret"
-	}
-	if companyName == "" {
-		companyName = "socialify"
-	}
-	if ownerName == "" {
-		ownerName = "userowner"
-	}
-	if ownerEmail == "" {
-		ownerEmail = "user@example.com"
-	}
-	if rollNo == "" {
-		rollNo = "123456"
-	}
-}
-
-func enableCORS(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-
-		next.ServeHTTP(w, r)
-	})
+	utils.SetCredentials(config.Credentials())
 }
 
 func registerHandler(w http.ResponseWriter, r *http.Request) {
@@ -81,7 +47,7 @@ func registerHandler(w http.ResponseWriter, r *http.Request) {
 
 	resp, err := utils.RegisterWithTestServer(req)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
@@ -102,19 +68,12 @@ func authHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp, err := utils.GetAuthToken(req)
+	resp, err := utils.GetAuthToken(r.Context(), req)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
-	clientID = req.ClientID
-	clientSecret = req.ClientSecret
-	companyName = req.CompanyName
-	ownerName = req.OwnerName
-	ownerEmail = req.OwnerEmail
-	rollNo = req.RollNo
-
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
 }
@@ -125,66 +84,119 @@ func topUsersHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	body, err := utils.FetchFromTestServer("/users", clientID, clientSecret, companyName, ownerName, ownerEmail, rollNo)
+	q, err := analytics.ParseTopUsersQuery(r.URL.Query())
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	var usersResp struct {
-		Users map[string]string `json:"users"`
+	res, err := service.TopUsers(r.Context(), q)
+	if err != nil {
+		writeError(w, r, err)
+		return
 	}
 
-	if err := json.Unmarshal(body, &usersResp); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	writeJSON(w, res)
+}
+
+// userHandler serves /api/users/{userId}/stats, /api/users/{userId}/topics,
+// /api/users/{userId}/sentiment, /api/users/{userId}/history and
+// /api/users/{userId}/interactions.
+func userHandler(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/users/"), "/")
+	if len(parts) != 2 || parts[0] == "" {
+		http.NotFound(w, r)
+		return
+	}
+	switch parts[1] {
+	case "stats", "topics", "sentiment", "history", "interactions":
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	userPostCounts := make([]models.UserPostCount, 0)
-
-	for id, name := range usersResp.Users {
-		postsURL := "/users/" + id + "/posts"
-		postsBody, err := utils.FetchFromTestServer(postsURL, clientID, clientSecret, companyName, ownerName, ownerEmail, rollNo)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+	userID := parts[0]
+	var res interface{}
+	var err error
+	switch parts[1] {
+	case "topics":
+		res, err = service.UserTopics(r.Context(), userID)
+	case "sentiment":
+		res, err = service.UserSentiment(r.Context(), userID)
+	case "history":
+		var q analytics.HistoryQuery
+		if q, err = analytics.ParseHistoryQuery(r.URL.Query()); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
-
-		var postsResp struct {
-			Posts []models.Post `json:"posts"`
+		res, err = service.UserRankHistory(userID, q)
+	case "interactions":
+		var page analytics.Page
+		if page, err = analytics.ParsePage(r.URL.Query()); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
 		}
-
-		if err := json.Unmarshal(postsBody, &postsResp); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+		res, err = service.UserInteractions(r.Context(), userID, page)
+	default:
+		var ranking string
+		if ranking, err = analytics.ParseRanking(r.URL.Query()); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
+		res, err = service.UserStats(r.Context(), userID, ranking)
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
 
-		userPostCounts = append(userPostCounts, models.UserPostCount{
-			User: models.User{
-				ID:   id,
-				Name: name,
-			},
-			PostCount: len(postsResp.Posts),
-		})
-	}
-
-	// Sort by post count (descending)
-	for i := 0; i < len(userPostCounts); i++ {
-		for j := i + 1; j < len(userPostCounts); j++ {
-			if userPostCounts[i].PostCount < userPostCounts[j].PostCount {
-				userPostCounts[i], userPostCounts[j] = userPostCounts[j], userPostCounts[i]
-			}
-		}
+	writeJSON(w, res)
+}
+
+func topicsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
 	}
 
-	if len(userPostCounts) > 5 {
-		userPostCounts = userPostCounts[:5]
+	page, err := analytics.ParsePage(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"topUsers": userPostCounts,
-	})
+	res, err := service.Topics(r.Context(), page)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, res)
+}
+
+// topicPostsHandler serves /api/topics/{topic}/posts.
+func topicPostsHandler(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/topics/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] != "posts" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	res, err := service.TopicPosts(r.Context(), parts[0])
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, res)
 }
 
 func latestPostsHandler(w http.ResponseWriter, r *http.Request) {
@@ -193,198 +205,305 @@ func latestPostsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	allPosts := make([]models.Post, 0)
-	userIDMap := make(map[string]string)
+	window, err := analytics.ParseWindow(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	body, err := utils.FetchFromTestServer("/users", clientID, clientSecret, companyName, ownerName, ownerEmail, rollNo)
+	res, err := service.LatestPosts(r.Context(), window)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
-	var usersResp struct {
-		Users map[string]string `json:"users"`
+	writeJSON(w, res)
+}
+
+func popularPostsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
 	}
 
-	if err := json.Unmarshal(body, &usersResp); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	q, err := analytics.ParsePopularQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	for id, name := range usersResp.Users {
-		userIDMap[id] = name
-		postsURL := "/users/" + id + "/posts"
-		postsBody, err := utils.FetchFromTestServer(postsURL, clientID, clientSecret, companyName, ownerName, ownerEmail, rollNo)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
+	res, err := service.PopularPosts(r.Context(), q)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
 
-		var postsResp struct {
-			Posts []models.Post `json:"posts"`
-		}
+	writeJSON(w, res)
+}
 
-		if err := json.Unmarshal(postsBody, &postsResp); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
+func trendingPostsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q, err := analytics.ParseTrendingQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-		allPosts = append(allPosts, postsResp.Posts...)
+	res, err := service.TrendingPosts(r.Context(), q)
+	if err != nil {
+		writeError(w, r, err)
+		return
 	}
 
-	// Sort by post ID (descending) for latest
-	for i := 0; i < len(allPosts); i++ {
-		for j := i + 1; j < len(allPosts); j++ {
-			if allPosts[i].ID < allPosts[j].ID {
-				allPosts[i], allPosts[j] = allPosts[j], allPosts[i]
-			}
+	writeJSON(w, res)
+}
+
+func sentimentPostsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q, err := analytics.ParseSentimentQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	res, err := service.SentimentPosts(r.Context(), q)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, res)
+}
+
+// postHandler serves /api/posts/{postId}/sentiment and
+// /api/posts/{postId}/history.
+func postHandler(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/posts/"), "/")
+	if len(parts) != 2 || (parts[1] != "sentiment" && parts[1] != "history") {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	postID, err := strconv.Atoi(parts[0])
+	if err != nil {
+		http.Error(w, "invalid post id", http.StatusBadRequest)
+		return
+	}
+
+	var res interface{}
+	if parts[1] == "history" {
+		var ranking string
+		if ranking, err = analytics.ParseRanking(r.URL.Query()); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
 		}
+		res, err = service.PostRankHistory(postID, ranking)
+	} else {
+		res, err = service.PostSentiment(r.Context(), postID)
 	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, res)
+}
 
-	if len(allPosts) > 5 {
-		allPosts = allPosts[:5]
+func searchHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
 	}
 
-	result := make([]map[string]interface{}, 0)
-	for _, post := range allPosts {
-		result = append(result, map[string]interface{}{
-			"post": post,
-			"user": map[string]interface{}{
-				"id":   post.UserID,
-				"name": userIDMap[post.UserID],
-			},
-		})
+	q, err := analytics.ParseSearchQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"latestPosts": result,
-	})
+	res, err := service.Search(r.Context(), q)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, res)
 }
 
-func popularPostsHandler(w http.ResponseWriter, r *http.Request) {
+func activityHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "GET" {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	type PostCommentCount struct {
-		Post         models.Post
-		CommentCount int
+	q, err := analytics.ParseActivityQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	res, err := service.Activity(r.Context(), q)
+	if err != nil {
+		writeError(w, r, err)
+		return
 	}
 
-	postCommentCounts := make([]PostCommentCount, 0)
-	userIDMap := make(map[string]string)
+	writeJSON(w, res)
+}
 
-	body, err := utils.FetchFromTestServer("/users", clientID, clientSecret, companyName, ownerName, ownerEmail, rollNo)
+func mutualPairsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	page, err := analytics.ParsePage(r.URL.Query())
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	var usersResp struct {
-		Users map[string]string `json:"users"`
+	res, err := service.MutualPairs(r.Context(), page)
+	if err != nil {
+		writeError(w, r, err)
+		return
 	}
 
-	if err := json.Unmarshal(body, &usersResp); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	writeJSON(w, res)
+}
+
+func influentialUsersHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	for id, name := range usersResp.Users {
-		userIDMap[id] = name
-		postsURL := "/users/" + id + "/posts"
-		postsBody, err := utils.FetchFromTestServer(postsURL, clientID, clientSecret, companyName, ownerName, ownerEmail, rollNo)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
+	q, err := analytics.ParseInfluenceQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-		var postsResp struct {
-			Posts []models.Post `json:"posts"`
-		}
+	res, err := service.InfluentialUsers(r.Context(), q)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
 
-		if err := json.Unmarshal(postsBody, &postsResp); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
+	writeJSON(w, res)
+}
 
-		for _, post := range postsResp.Posts {
-			commentsURL := "/posts/" + strconv.Itoa(post.ID) + "/comments"
-			commentsBody, err := utils.FetchFromTestServer(commentsURL, clientID, clientSecret, companyName, ownerName, ownerEmail, rollNo)
-			if err != nil {
-				continue
-			}
-
-			var commentsResp struct {
-				Comments []models.Comment `json:"comments"`
-			}
-
-			if err := json.Unmarshal(commentsBody, &commentsResp); err != nil {
-				continue
-			}
-
-			postCommentCounts = append(postCommentCounts, PostCommentCount{
-				Post:         post,
-				CommentCount: len(commentsResp.Comments),
-			})
-		}
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func cacheHandler(w http.ResponseWriter, r *http.Request) {
+	cache, ok := datasource.CacheOf(source)
+	if !ok {
+		http.Error(w, "Cache disabled", http.StatusNotFound)
+		return
 	}
 
-	// Sort by comment count (descending)
-	for i := 0; i < len(postCommentCounts); i++ {
-		for j := i + 1; j < len(postCommentCounts); j++ {
-			if postCommentCounts[i].CommentCount < postCommentCounts[j].CommentCount {
-				postCommentCounts[i], postCommentCounts[j] = postCommentCounts[j], postCommentCounts[i]
-			}
+	switch r.Method {
+	case "GET":
+		writeJSON(w, cache.Stats())
+	case "DELETE":
+		if err := cache.Invalidate(r.URL.Query().Get("resource"), r.URL.Query().Get("id")); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
 		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 	}
+}
 
-	maxCommentCount := 0
-	if len(postCommentCounts) > 0 {
-		maxCommentCount = postCommentCounts[0].CommentCount
-	}
-
-	popularPosts := make([]map[string]interface{}, 0)
-	for _, pc := range postCommentCounts {
-		if pc.CommentCount == maxCommentCount {
-			popularPosts = append(popularPosts, map[string]interface{}{
-				"post": pc.Post,
-				"user": map[string]interface{}{
-					"id":   pc.Post.UserID,
-					"name": userIDMap[pc.Post.UserID],
-				},
-				"commentCount": pc.CommentCount,
-			})
-		} else {
-			break
-		}
+func upstreamStateHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
 	}
 
+	writeJSON(w, map[string]interface{}{"endpoints": upstream.Default.State()})
+}
+
+// writeError sends the client-facing form of err. The full error is only
+// logged.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	p := upstream.Describe(err)
+	log.Printf("%s %s: %d %s: %v", r.Method, r.URL.Path, p.Status, p.Code, err)
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"popularPosts": popularPosts,
-	})
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
 }
 
 // SetupRoutes configures all HTTP routes for the server
 func SetupRoutes() {
-	http.Handle("/api/auth/register", enableCORS(http.HandlerFunc(registerHandler)))
-	http.Handle("/api/auth/token", enableCORS(http.HandlerFunc(authHandler)))
-	http.Handle("/api/users/top", enableCORS(http.HandlerFunc(topUsersHandler)))
-	http.Handle("/api/posts/latest", enableCORS(http.HandlerFunc(latestPostsHandler)))
-	http.Handle("/api/posts/popular", enableCORS(http.HandlerFunc(popularPostsHandler)))
+	http.Handle("/api/auth/register", middleware.CORS(http.HandlerFunc(registerHandler)))
+	http.Handle("/api/auth/token", middleware.CORS(http.HandlerFunc(authHandler)))
+	http.Handle("/api/users/top", middleware.CORS(middleware.Timeout(config.ListTimeout, http.HandlerFunc(topUsersHandler))))
+	http.Handle("/api/users/influential", middleware.CORS(middleware.Timeout(config.PopularTimeout, http.HandlerFunc(influentialUsersHandler))))
+	http.Handle("/api/users/", middleware.CORS(middleware.Timeout(config.ListTimeout, http.HandlerFunc(userHandler))))
+	http.Handle("/api/posts/latest", middleware.CORS(middleware.Timeout(config.ListTimeout, http.HandlerFunc(latestPostsHandler))))
+	http.Handle("/api/posts/popular", middleware.CORS(middleware.Timeout(config.PopularTimeout, http.HandlerFunc(popularPostsHandler))))
+	http.Handle("/api/posts/trending", middleware.CORS(middleware.Timeout(config.PopularTimeout, http.HandlerFunc(trendingPostsHandler))))
+	http.Handle("/api/posts/sentiment", middleware.CORS(middleware.Timeout(config.PopularTimeout, http.HandlerFunc(sentimentPostsHandler))))
+	http.Handle("/api/posts/", middleware.CORS(middleware.Timeout(config.ListTimeout, http.HandlerFunc(postHandler))))
+	http.Handle("/api/topics", middleware.CORS(middleware.Timeout(config.ListTimeout, http.HandlerFunc(topicsHandler))))
+	http.Handle("/api/topics/", middleware.CORS(middleware.Timeout(config.ListTimeout, http.HandlerFunc(topicPostsHandler))))
+	http.Handle("/api/search", middleware.CORS(middleware.Timeout(config.PopularTimeout, http.HandlerFunc(searchHandler))))
+	http.Handle("/api/activity", middleware.CORS(middleware.Timeout(config.PopularTimeout, http.HandlerFunc(activityHandler))))
+	http.Handle("/api/interactions/mutual", middleware.CORS(middleware.Timeout(config.PopularTimeout, http.HandlerFunc(mutualPairsHandler))))
+	http.Handle("/api/admin/cache", middleware.CORS(http.HandlerFunc(cacheHandler)))
+	http.Handle("/api/admin/upstream", middleware.CORS(http.HandlerFunc(upstreamStateHandler)))
 }
 
 func main() {
+	cfg := config.FromEnv()
+	cfg.RegisterFlags(flag.CommandLine)
+	flag.Parse()
+
+	var err error
+	source, err = cfg.OpenSource()
+	if err != nil {
+		log.Fatal(err)
+	}
+	service = analytics.New(fetch.New(source, cfg.Concurrency))
+
+	if cfg.RefreshInterval > 0 {
+		worker := ingest.NewWorker(service.Fetcher, cfg.RefreshInterval)
+		service.Attach(worker)
+		go worker.Run(context.Background())
+	}
+
+	if service.History, err = history.Open(cfg.HistoryPath); err != nil {
+		log.Fatal(err)
+	}
+	if cfg.SnapshotInterval > 0 {
+		go service.RecordHistory(context.Background(), cfg.SnapshotInterval)
+	}
+
 	SetupRoutes()
 
 	server := &http.Server{
-		Addr:         ":8081",
+		Addr:         ":" + cfg.Port,
 		ReadTimeout:  60 * time.Second,
 		WriteTimeout: 60 * time.Second,
 	}
 
-	fmt.Println("Server is running on port 8081...")
+	fmt.Printf("Server is running on port %s...\n", cfg.Port)
 	log.Fatal(server.ListenAndServe())
 }
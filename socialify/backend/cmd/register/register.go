@@ -1,6 +1,7 @@
 package register
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"socialify/backend/models"
@@ -34,7 +35,7 @@ func RegisterWithServer() {
 		RollNo:       resp.RollNo,
 	}
 
-	authResp, err := utils.GetAuthToken(authReq)
+	authResp, err := utils.GetAuthToken(context.Background(), authReq)
 	if err != nil {
 		fmt.Printf("Auth error: %s\n", err)
 		return
@@ -1,26 +1,96 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
+	"socialify/backend/config"
+	"socialify/backend/handlers"
+	"socialify/backend/history"
+	"socialify/backend/ingest"
+	"socialify/backend/middleware"
 	"time"
+
+	"github.com/gin-gonic/gin"
 )
 
+// setupRouter mounts the gin handlers under /api.
+func setupRouter() *gin.Engine {
+	router := gin.New()
+	router.Use(gin.Logger(), gin.Recovery())
+
+	api := router.Group("/api")
+	api.POST("/auth/register", handlers.Register)
+	api.POST("/auth/token", handlers.Auth)
+
+	api.GET("/users", handlers.Timeout(config.ListTimeout), handlers.GetUsers)
+	api.GET("/users/top", handlers.Timeout(config.ListTimeout), handlers.GetTopUsers)
+	api.GET("/users/influential", handlers.Timeout(config.PopularTimeout), handlers.GetInfluentialUsers)
+	api.GET("/users/:userId/posts", handlers.Timeout(config.ListTimeout), handlers.GetUserPosts)
+	api.GET("/users/:userId/stats", handlers.Timeout(config.ListTimeout), handlers.GetUserStats)
+	api.GET("/users/:userId/topics", handlers.Timeout(config.ListTimeout), handlers.GetUserTopics)
+	api.GET("/users/:userId/sentiment", handlers.Timeout(config.ListTimeout), handlers.GetUserSentiment)
+	api.GET("/users/:userId/history", handlers.GetUserRankHistory)
+	api.GET("/users/:userId/interactions", handlers.Timeout(config.PopularTimeout), handlers.GetUserInteractions)
+
+	api.GET("/posts/latest", handlers.Timeout(config.ListTimeout), handlers.GetLatestPosts)
+	api.GET("/posts/popular", handlers.Timeout(config.PopularTimeout), handlers.GetPopularPosts)
+	api.GET("/posts/trending", handlers.Timeout(config.PopularTimeout), handlers.GetTrendingPosts)
+	api.GET("/posts/sentiment", handlers.Timeout(config.PopularTimeout), handlers.GetSentimentPosts)
+	api.GET("/posts/:postId/comments", handlers.Timeout(config.ListTimeout), handlers.GetPostComments)
+	api.GET("/posts/:postId/sentiment", handlers.Timeout(config.ListTimeout), handlers.GetPostSentiment)
+	api.GET("/posts/:postId/history", handlers.GetPostRankHistory)
+
+	api.GET("/topics", handlers.Timeout(config.ListTimeout), handlers.GetTopics)
+	api.GET("/topics/:topic/posts", handlers.Timeout(config.ListTimeout), handlers.GetTopicPosts)
+
+	api.GET("/search", handlers.Timeout(config.PopularTimeout), handlers.Search)
+	api.GET("/activity", handlers.Timeout(config.PopularTimeout), handlers.GetActivity)
+	api.GET("/interactions/mutual", handlers.Timeout(config.PopularTimeout), handlers.GetMutualPairs)
+
+	api.GET("/admin/cache", handlers.GetCacheStats)
+	api.DELETE("/admin/cache", handlers.InvalidateCache)
+	api.GET("/admin/upstream", handlers.GetUpstreamState)
+
+	return router
+}
+
 func main() {
-	// Call the standalone API server directly
-	http.Handle("/api/auth/register", http.NotFoundHandler())
-	http.Handle("/api/auth/token", http.NotFoundHandler())
-	http.Handle("/api/users/top", http.NotFoundHandler())
-	http.Handle("/api/posts/latest", http.NotFoundHandler())
-	http.Handle("/api/posts/popular", http.NotFoundHandler())
+	cfg := config.FromEnv()
+	cfg.RegisterFlags(flag.CommandLine)
+	flag.Parse()
+
+	source, err := cfg.OpenSource()
+	if err != nil {
+		log.Fatal(err)
+	}
+	handlers.SetDataSource(source)
+	handlers.SetFetchConcurrency(cfg.Concurrency)
+
+	if cfg.RefreshInterval > 0 {
+		worker := ingest.NewWorker(handlers.Fetcher(), cfg.RefreshInterval)
+		handlers.AttachWorker(worker)
+		go worker.Run(context.Background())
+	}
+
+	store, err := history.Open(cfg.HistoryPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	handlers.SetHistory(store)
+	if cfg.SnapshotInterval > 0 {
+		go handlers.RecordHistory(context.Background(), cfg.SnapshotInterval)
+	}
 
 	httpServer := &http.Server{
-		Addr:         ":8081",
+		Addr:         ":" + cfg.Port,
+		Handler:      middleware.CORS(setupRouter()),
 		ReadTimeout:  60 * time.Second,
 		WriteTimeout: 60 * time.Second,
 	}
 
-	fmt.Println("Server is running on port 8081...")
+	fmt.Printf("Server is running on port %s...\n", cfg.Port)
 	log.Fatal(httpServer.ListenAndServe())
 }
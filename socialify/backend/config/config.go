@@ -0,0 +1,87 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"socialify/backend/datasource"
+	"socialify/backend/fetch"
+	"socialify/backend/history"
+	"socialify/backend/ingest"
+	"socialify/backend/models"
+	"time"
+)
+
+// Deadlines for the analytics endpoints. Popular posts also reads every
+// post's comments, so it gets longer.
+const (
+	ListTimeout    = 15 * time.Second
+	PopularTimeout = 30 * time.Second
+)
+
+// Config holds the settings shared by the API servers. Fields default from
+// the environment and can be overridden with flags.
+type Config struct {
+	Port            string
+	DBPath          string
+	Concurrency     int
+	RefreshInterval time.Duration
+	// HistoryPath is where leaderboard snapshots are kept; empty keeps
+	// them in memory.
+	HistoryPath      string
+	SnapshotInterval time.Duration
+}
+
+func FromEnv() *Config {
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8081"
+	}
+	return &Config{
+		Port:             port,
+		Concurrency:      fetch.LimitFromEnv(),
+		RefreshInterval:  ingest.IntervalFromEnv(),
+		HistoryPath:      history.PathFromEnv(),
+		SnapshotInterval: history.IntervalFromEnv(),
+	}
+}
+
+func (c *Config) RegisterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.Port, "port", c.Port, "port to listen on")
+	fs.StringVar(&c.DBPath, "db", c.DBPath, "serve from the SQLite database at this path instead of DATA_SOURCE")
+	fs.IntVar(&c.Concurrency, "concurrency", c.Concurrency, "maximum concurrent upstream calls per request")
+	fs.DurationVar(&c.RefreshInterval, "refresh", c.RefreshInterval, "how often to rebuild the leaderboards in the background (0 disables)")
+	fs.StringVar(&c.HistoryPath, "history", c.HistoryPath, "file to keep leaderboard snapshots in (empty keeps them in memory)")
+	fs.DurationVar(&c.SnapshotInterval, "snapshot", c.SnapshotInterval, "how often to snapshot the leaderboards (0 disables)")
+}
+
+// OpenSource opens the configured data source behind the cache.
+func (c *Config) OpenSource() (datasource.DataSource, error) {
+	if c.DBPath == "" {
+		return datasource.FromEnv()
+	}
+	db, err := datasource.NewSQLite(c.DBPath)
+	if err != nil {
+		return nil, err
+	}
+	return datasource.CacheFromEnv(db), nil
+}
+
+// Credentials reads the test server client credentials from the
+// environment, with demo values for anything unset.
+func Credentials() models.AuthRequest {
+	return models.AuthRequest{
+		ClientID:     getenv("CLIENT_ID", "demo_client_id"),
+		ClientSecret: getenv("CLIENT_SECRET", "demo_client_secret"),
+		CompanyName:  getenv("COMPANY_NAME", "socialify"),
+		OwnerName:    getenv("OWNER_NAME", "userowner"),
+		OwnerEmail:   getenv("OWNER_EMAIL", "user@example.com"),
+		RollNo:       getenv("ROLL_NO", "123456"),
+	}
+}
+
+func getenv(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
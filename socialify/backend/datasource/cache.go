@@ -0,0 +1,337 @@
+package datasource
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"socialify/backend/models"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache resources. They name the counters in CacheStats and the scopes
+// accepted by Invalidate.
+const (
+	ResourceUsers      = "users"
+	ResourcePosts      = "posts"
+	ResourceComments   = "comments"
+	ResourceAggregates = "aggregates"
+)
+
+// CacheTTLs sets how long each resource stays fresh. A zero TTL disables
+// caching for that resource; concurrent identical reads are still coalesced.
+type CacheTTLs struct {
+	Users    time.Duration
+	Posts    time.Duration
+	Comments time.Duration
+}
+
+var DefaultCacheTTLs = CacheTTLs{
+	Users:    5 * time.Minute,
+	Posts:    time.Minute,
+	Comments: 30 * time.Second,
+}
+
+// CacheTTLsFromEnv reads CACHE_TTL_USERS, CACHE_TTL_POSTS and
+// CACHE_TTL_COMMENTS, falling back to DefaultCacheTTLs.
+func CacheTTLsFromEnv() CacheTTLs {
+	ttls := DefaultCacheTTLs
+	for env, ttl := range map[string]*time.Duration{
+		"CACHE_TTL_USERS":    &ttls.Users,
+		"CACHE_TTL_POSTS":    &ttls.Posts,
+		"CACHE_TTL_COMMENTS": &ttls.Comments,
+	} {
+		if d, err := time.ParseDuration(os.Getenv(env)); err == nil && d >= 0 {
+			*ttl = d
+		}
+	}
+	return ttls
+}
+
+type CacheCounters struct {
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Coalesced uint64 `json:"coalesced"`
+}
+
+type CacheStats struct {
+	Entries   int                      `json:"entries"`
+	Resources map[string]CacheCounters `json:"resources"`
+}
+
+// Cached is a read-through cache in front of another data source. Reads of
+// the same resource that overlap share one upstream call, which is cancelled
+// once every caller waiting on it has gone away.
+type Cached struct {
+	next DataSource
+	ttls CacheTTLs
+
+	mu       sync.Mutex
+	entries  map[string]cacheEntry
+	calls    map[string]*cacheCall
+	counters map[string]*CacheCounters
+}
+
+type cacheEntry struct {
+	value   interface{}
+	expires time.Time
+}
+
+type cacheCall struct {
+	done    chan struct{}
+	cancel  context.CancelFunc
+	waiters int
+	value   interface{}
+	err     error
+}
+
+// CachedAggregator is a Cached whose underlying source is an Aggregator. The
+// aggregates are cached with the posts TTL. Windowed aggregates are not
+// cached: their keys would follow whatever windows callers ask for.
+type CachedAggregator struct {
+	*Cached
+	agg Aggregator
+}
+
+// NewCached wraps next in a cache. The result implements Aggregator exactly
+// when next does.
+func NewCached(next DataSource, ttls CacheTTLs) DataSource {
+	c := &Cached{
+		next:     next,
+		ttls:     ttls,
+		entries:  make(map[string]cacheEntry),
+		calls:    make(map[string]*cacheCall),
+		counters: make(map[string]*CacheCounters),
+	}
+	if agg, ok := next.(Aggregator); ok {
+		return &CachedAggregator{Cached: c, agg: agg}
+	}
+	return c
+}
+
+// CacheOf returns the cache in front of ds, if there is one.
+func CacheOf(ds DataSource) (*Cached, bool) {
+	switch c := ds.(type) {
+	case *Cached:
+		return c, true
+	case *CachedAggregator:
+		return c.Cached, true
+	}
+	return nil, false
+}
+
+func (c *Cached) ListUsers(ctx context.Context) ([]models.User, error) {
+	v, err := c.load(ctx, ResourceUsers, ResourceUsers, c.ttls.Users, func(ctx context.Context) (interface{}, error) {
+		return c.next.ListUsers(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append([]models.User(nil), v.([]models.User)...), nil
+}
+
+func (c *Cached) ListPostsByUser(ctx context.Context, userID string) ([]models.Post, error) {
+	v, err := c.load(ctx, ResourcePosts, ResourcePosts+":"+userID, c.ttls.Posts, func(ctx context.Context) (interface{}, error) {
+		return c.next.ListPostsByUser(ctx, userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append(make([]models.Post, 0), v.([]models.Post)...), nil
+}
+
+func (c *Cached) ListCommentsByPost(ctx context.Context, postID int) ([]models.Comment, error) {
+	v, err := c.load(ctx, ResourceComments, ResourceComments+":"+strconv.Itoa(postID), c.ttls.Comments, func(ctx context.Context) (interface{}, error) {
+		return c.next.ListCommentsByPost(ctx, postID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append(make([]models.Comment, 0), v.([]models.Comment)...), nil
+}
+
+func (c *CachedAggregator) TopUsersByPostCount(ctx context.Context, limit int) ([]models.UserPostCount, error) {
+	key := fmt.Sprintf("%s:top-users:%d", ResourceAggregates, limit)
+	v, err := c.load(ctx, ResourceAggregates, key, c.ttls.Posts, func(ctx context.Context) (interface{}, error) {
+		return c.agg.TopUsersByPostCount(ctx, limit)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append([]models.UserPostCount(nil), v.([]models.UserPostCount)...), nil
+}
+
+func (c *CachedAggregator) LatestPosts(ctx context.Context, limit int, window Window) ([]models.PostWithUser, error) {
+	if !window.IsZero() {
+		return c.agg.LatestPosts(ctx, limit, window)
+	}
+	key := fmt.Sprintf("%s:latest-posts:%d", ResourceAggregates, limit)
+	v, err := c.load(ctx, ResourceAggregates, key, c.ttls.Posts, func(ctx context.Context) (interface{}, error) {
+		return c.agg.LatestPosts(ctx, limit, window)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append([]models.PostWithUser(nil), v.([]models.PostWithUser)...), nil
+}
+
+func (c *CachedAggregator) TopPostsByCommentCount(ctx context.Context, limit, minComments int, window Window) ([]models.PostWithUser, error) {
+	if !window.IsZero() {
+		return c.agg.TopPostsByCommentCount(ctx, limit, minComments, window)
+	}
+	key := fmt.Sprintf("%s:top-posts:%d:%d", ResourceAggregates, limit, minComments)
+	v, err := c.load(ctx, ResourceAggregates, key, c.ttls.Posts, func(ctx context.Context) (interface{}, error) {
+		return c.agg.TopPostsByCommentCount(ctx, limit, minComments, window)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append([]models.PostWithUser(nil), v.([]models.PostWithUser)...), nil
+}
+
+// load returns the fresh cached value for key or fetches it, joining a fetch
+// that is already in flight for the same key.
+func (c *Cached) load(ctx context.Context, resource, key string, ttl time.Duration, fetch func(context.Context) (interface{}, error)) (interface{}, error) {
+	c.mu.Lock()
+	counters := c.countersLocked(resource)
+	if e, ok := c.entries[key]; ok {
+		if time.Now().Before(e.expires) {
+			counters.Hits++
+			c.mu.Unlock()
+			return e.value, nil
+		}
+		delete(c.entries, key)
+	}
+
+	call, ok := c.calls[key]
+	if ok {
+		counters.Coalesced++
+	} else {
+		counters.Misses++
+		// The shared call must outlive whichever caller started it, so it gets
+		// its own context and is cancelled when its last waiter leaves.
+		callCtx, cancel := context.WithCancel(context.Background())
+		call = &cacheCall{done: make(chan struct{}), cancel: cancel}
+		c.calls[key] = call
+		go c.run(callCtx, key, ttl, call, fetch)
+	}
+	call.waiters++
+	c.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.value, call.err
+	case <-ctx.Done():
+		c.mu.Lock()
+		call.waiters--
+		if call.waiters == 0 {
+			call.cancel()
+			if c.calls[key] == call {
+				delete(c.calls, key)
+			}
+		}
+		c.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+func (c *Cached) run(ctx context.Context, key string, ttl time.Duration, call *cacheCall, fetch func(context.Context) (interface{}, error)) {
+	defer call.cancel()
+	value, err := fetch(ctx)
+
+	c.mu.Lock()
+	call.value, call.err = value, err
+	// A call that was invalidated or abandoned while in flight may carry
+	// stale data and is not stored.
+	if c.calls[key] == call {
+		delete(c.calls, key)
+		if err == nil && ttl > 0 {
+			now := time.Now()
+			c.sweepLocked(now)
+			c.entries[key] = cacheEntry{value: value, expires: now.Add(ttl)}
+		}
+	}
+	c.mu.Unlock()
+	close(call.done)
+}
+
+// sweepLocked drops the entries that have expired, so keys that are never
+// read again do not stay in memory. It runs whenever an entry is stored.
+func (c *Cached) sweepLocked(now time.Time) {
+	for key, e := range c.entries {
+		if !now.Before(e.expires) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+func (c *Cached) countersLocked(resource string) *CacheCounters {
+	counters, ok := c.counters[resource]
+	if !ok {
+		counters = &CacheCounters{}
+		c.counters[resource] = counters
+	}
+	return counters
+}
+
+// Invalidate drops cached entries so the next read goes upstream. resource
+// is one of the Resource* constants, or empty for everything; id narrows
+// posts to one user and comments to one post. Anything that changes posts or
+// comments also drops the aggregates derived from them.
+func (c *Cached) Invalidate(resource, id string) error {
+	aggregate := func(key string) bool {
+		return strings.HasPrefix(key, ResourceAggregates+":")
+	}
+
+	var match func(key string) bool
+	switch resource {
+	case "":
+		match = func(string) bool { return true }
+	case ResourceUsers:
+		match = func(key string) bool { return key == ResourceUsers || aggregate(key) }
+	case ResourcePosts, ResourceComments:
+		match = func(key string) bool {
+			if id == "" {
+				return strings.HasPrefix(key, resource+":") || aggregate(key)
+			}
+			return key == resource+":"+id || aggregate(key)
+		}
+	case ResourceAggregates:
+		match = aggregate
+	default:
+		return fmt.Errorf("unknown cache resource %q", resource)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.entries {
+		if match(key) {
+			delete(c.entries, key)
+		}
+	}
+	// In-flight calls are forgotten rather than cancelled; their callers still
+	// get a result, but it is not stored.
+	for key := range c.calls {
+		if match(key) {
+			delete(c.calls, key)
+		}
+	}
+	return nil
+}
+
+// Stats returns a snapshot of the cache counters.
+func (c *Cached) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := CacheStats{
+		Entries:   len(c.entries),
+		Resources: make(map[string]CacheCounters, len(c.counters)),
+	}
+	for resource, counters := range c.counters {
+		stats.Resources[resource] = *counters
+	}
+	return stats
+}
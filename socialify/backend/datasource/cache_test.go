@@ -0,0 +1,321 @@
+package datasource
+
+import (
+	"context"
+	"errors"
+	"socialify/backend/models"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingSource counts the calls that reach the mock data and, if gate is
+// set, holds every call until it is closed.
+type countingSource struct {
+	DataSource
+	gate     chan struct{}
+	users    int32
+	posts    int32
+	comments int32
+}
+
+func newCountingSource() *countingSource {
+	return &countingSource{DataSource: NewMock()}
+}
+
+type callCounts struct {
+	users, posts, comments int32
+}
+
+func (s *countingSource) counts() callCounts {
+	return callCounts{atomic.LoadInt32(&s.users), atomic.LoadInt32(&s.posts), atomic.LoadInt32(&s.comments)}
+}
+
+func (s *countingSource) wait(ctx context.Context) error {
+	if s.gate == nil {
+		return nil
+	}
+	select {
+	case <-s.gate:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *countingSource) ListUsers(ctx context.Context) ([]models.User, error) {
+	atomic.AddInt32(&s.users, 1)
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+	return s.DataSource.ListUsers(ctx)
+}
+
+func (s *countingSource) ListPostsByUser(ctx context.Context, userID string) ([]models.Post, error) {
+	atomic.AddInt32(&s.posts, 1)
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+	return s.DataSource.ListPostsByUser(ctx, userID)
+}
+
+func (s *countingSource) ListCommentsByPost(ctx context.Context, postID int) ([]models.Comment, error) {
+	atomic.AddInt32(&s.comments, 1)
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+	return s.DataSource.ListCommentsByPost(ctx, postID)
+}
+
+func TestCachedCoalescesConcurrentReads(t *testing.T) {
+	src := newCountingSource()
+	src.gate = make(chan struct{})
+	c, _ := CacheOf(NewCached(src, DefaultCacheTTLs))
+
+	const callers = 50
+	var wg sync.WaitGroup
+	errs := make([]error, callers)
+	lens := make([]int, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			users, err := c.ListUsers(context.Background())
+			errs[i], lens[i] = err, len(users)
+		}(i)
+	}
+
+	// Let every caller join the call before it completes.
+	for {
+		c.mu.Lock()
+		call := c.calls[ResourceUsers]
+		joined := call != nil && call.waiters == callers
+		c.mu.Unlock()
+		if joined {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(src.gate)
+	wg.Wait()
+
+	for i := range errs {
+		if errs[i] != nil || lens[i] == 0 {
+			t.Fatalf("caller %d: %d users, %v", i, lens[i], errs[i])
+		}
+	}
+	if src.users != 1 {
+		t.Errorf("upstream calls = %d, want 1", src.users)
+	}
+	got := c.Stats().Resources[ResourceUsers]
+	want := CacheCounters{Misses: 1, Coalesced: callers - 1}
+	if got != want {
+		t.Errorf("counters = %+v, want %+v", got, want)
+	}
+}
+
+func TestCachedAbandonedCallIsCancelled(t *testing.T) {
+	src := newCountingSource()
+	src.gate = make(chan struct{})
+	defer close(src.gate)
+	c, _ := CacheOf(NewCached(src, DefaultCacheTTLs))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := c.ListUsers(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.calls) != 0 || len(c.entries) != 0 {
+		t.Errorf("%d calls and %d entries left after the only caller left", len(c.calls), len(c.entries))
+	}
+}
+
+func TestCachedHitsAndExpiry(t *testing.T) {
+	src := newCountingSource()
+	c, _ := CacheOf(NewCached(src, CacheTTLs{Users: time.Hour, Posts: 30 * time.Millisecond}))
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.ListUsers(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.ListPostsByUser(ctx, "1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if src.users != 1 || src.posts != 1 {
+		t.Fatalf("upstream calls = %d users, %d posts, want 1 each", src.users, src.posts)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if _, err := c.ListUsers(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.ListPostsByUser(ctx, "1"); err != nil {
+		t.Fatal(err)
+	}
+	if src.users != 1 {
+		t.Errorf("users read %d times before their TTL, want 1", src.users)
+	}
+	if src.posts != 2 {
+		t.Errorf("posts read %d times across their TTL, want 2", src.posts)
+	}
+
+	stats := c.Stats()
+	if got, want := stats.Resources[ResourceUsers], (CacheCounters{Hits: 3, Misses: 1}); got != want {
+		t.Errorf("users counters = %+v, want %+v", got, want)
+	}
+	if got, want := stats.Resources[ResourcePosts], (CacheCounters{Hits: 2, Misses: 2}); got != want {
+		t.Errorf("posts counters = %+v, want %+v", got, want)
+	}
+	if stats.Entries != 2 {
+		t.Errorf("entries = %d, want 2", stats.Entries)
+	}
+}
+
+func TestCachedZeroTTL(t *testing.T) {
+	src := newCountingSource()
+	c, _ := CacheOf(NewCached(src, CacheTTLs{}))
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.ListCommentsByPost(context.Background(), 150); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if src.comments != 3 {
+		t.Errorf("upstream calls = %d, want 3", src.comments)
+	}
+}
+
+func TestCachedDropsExpiredEntries(t *testing.T) {
+	src := newCountingSource()
+	c, _ := CacheOf(NewCached(src, CacheTTLs{Users: time.Hour, Comments: 20 * time.Millisecond}))
+	ctx := context.Background()
+
+	posts := []int{150, 161, 246, 370}
+	for _, id := range posts {
+		if _, err := c.ListCommentsByPost(ctx, id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := c.Stats().Entries; n != len(posts) {
+		t.Fatalf("entries = %d, want %d", n, len(posts))
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if _, err := c.ListUsers(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := c.Stats().Entries; n != 1 {
+		t.Errorf("entries = %d after the comments expired, want 1", n)
+	}
+}
+
+// countingAggregator counts the aggregate calls that reach it.
+type countingAggregator struct {
+	*countingSource
+	latest int32
+	top    int32
+}
+
+func (a *countingAggregator) TopUsersByPostCount(ctx context.Context, limit int) ([]models.UserPostCount, error) {
+	return []models.UserPostCount{}, nil
+}
+
+func (a *countingAggregator) LatestPosts(ctx context.Context, limit int, window Window) ([]models.PostWithUser, error) {
+	atomic.AddInt32(&a.latest, 1)
+	return []models.PostWithUser{}, nil
+}
+
+func (a *countingAggregator) TopPostsByCommentCount(ctx context.Context, limit, minComments int, window Window) ([]models.PostWithUser, error) {
+	atomic.AddInt32(&a.top, 1)
+	return []models.PostWithUser{}, nil
+}
+
+func TestCachedAggregatorSkipsWindows(t *testing.T) {
+	src := &countingAggregator{countingSource: newCountingSource()}
+	ds := NewCached(src, DefaultCacheTTLs)
+	agg := ds.(Aggregator)
+	c, _ := CacheOf(ds)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		agg.LatestPosts(ctx, 5, Window{})
+		agg.TopPostsByCommentCount(ctx, 5, 1, Window{})
+	}
+	const windows = 20
+	for i := 0; i < windows; i++ {
+		w := Window{Since: time.Date(2025, 3, 1, 0, i, 0, 0, time.UTC)}
+		agg.LatestPosts(ctx, 5, w)
+		agg.TopPostsByCommentCount(ctx, 5, 1, w)
+	}
+
+	if src.latest != windows+1 || src.top != windows+1 {
+		t.Errorf("aggregate calls = %d latest, %d top, want %d each", src.latest, src.top, windows+1)
+	}
+	if n := c.Stats().Entries; n != 2 {
+		t.Errorf("entries = %d, want only the 2 unwindowed aggregates", n)
+	}
+}
+
+func TestCachedInvalidate(t *testing.T) {
+	tests := []struct {
+		resource, id string
+		// dropped are the reads that go upstream again, of users, posts of
+		// users 1 and 2 and comments on posts 150 and 246.
+		dropped [5]bool
+	}{
+		{"", "", [5]bool{true, true, true, true, true}},
+		{ResourceUsers, "", [5]bool{true, false, false, false, false}},
+		{ResourcePosts, "", [5]bool{false, true, true, false, false}},
+		{ResourcePosts, "1", [5]bool{false, true, false, false, false}},
+		{ResourceComments, "", [5]bool{false, false, false, true, true}},
+		{ResourceComments, "246", [5]bool{false, false, false, false, true}},
+		{ResourceAggregates, "", [5]bool{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.resource+":"+tt.id, func(t *testing.T) {
+			src := newCountingSource()
+			c, _ := CacheOf(NewCached(src, DefaultCacheTTLs))
+			ctx := context.Background()
+
+			read := func() {
+				c.ListUsers(ctx)
+				c.ListPostsByUser(ctx, "1")
+				c.ListPostsByUser(ctx, "2")
+				c.ListCommentsByPost(ctx, 150)
+				c.ListCommentsByPost(ctx, 246)
+			}
+			read()
+			if err := c.Invalidate(tt.resource, tt.id); err != nil {
+				t.Fatal(err)
+			}
+			want := src.counts()
+			read()
+
+			for i, dropped := range tt.dropped {
+				switch {
+				case !dropped:
+				case i == 0:
+					want.users++
+				case i <= 2:
+					want.posts++
+				default:
+					want.comments++
+				}
+			}
+			if got := src.counts(); got != want {
+				t.Errorf("upstream calls after invalidating = %+v, want %+v", got, want)
+			}
+		})
+	}
+
+	c, _ := CacheOf(NewCached(newCountingSource(), DefaultCacheTTLs))
+	if err := c.Invalidate("bogus", ""); err == nil {
+		t.Error("expected an error for an unknown resource")
+	}
+}
@@ -0,0 +1,163 @@
+package datasource
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"socialify/backend/models"
+	"socialify/backend/utils"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// DataSource is the read side of the social network the analytics handlers
+// work on. Implementations must be safe for concurrent use and should stop
+// work as soon as ctx is done.
+type DataSource interface {
+	ListUsers(ctx context.Context) ([]models.User, error)
+	ListPostsByUser(ctx context.Context, userID string) ([]models.Post, error)
+	ListCommentsByPost(ctx context.Context, postID int) ([]models.Comment, error)
+}
+
+// Aggregator is implemented by sources that can compute the dashboard
+// aggregates themselves, e.g. in SQL, instead of having the handlers derive
+// them from the raw lists.
+type Aggregator interface {
+	// TopUsersByPostCount returns the first limit users in LessUserPostCount
+	// order.
+	TopUsersByPostCount(ctx context.Context, limit int) ([]models.UserPostCount, error)
+	// LatestPosts returns the first limit posts created within window in
+	// NewerPost order.
+	LatestPosts(ctx context.Context, limit int, window Window) ([]models.PostWithUser, error)
+	// TopPostsByCommentCount returns the first limit posts created within
+	// window with at least minComments comments, most commented first and
+	// ties by ID.
+	TopPostsByCommentCount(ctx context.Context, limit, minComments int, window Window) ([]models.PostWithUser, error)
+}
+
+const (
+	KindMock   = "mock"
+	KindHTTP   = "http"
+	KindFile   = "file"
+	KindSQLite = "sqlite"
+)
+
+// New builds the data source named by kind. path is only used by kinds that
+// read from disk.
+func New(kind, path string) (DataSource, error) {
+	switch kind {
+	case "", KindMock:
+		return NewMock(), nil
+	case KindHTTP:
+		h := NewHTTP(utils.ServerURL(), utils.Tokens())
+		if d, err := time.ParseDuration(os.Getenv("UPSTREAM_TIMEOUT")); err == nil && d > 0 {
+			h.CallTimeout = d
+		}
+		return h, nil
+	case KindFile:
+		if path == "" {
+			return nil, fmt.Errorf("data source %q requires DATA_SOURCE_PATH", kind)
+		}
+		return NewFile(path)
+	case KindSQLite:
+		if path == "" {
+			return nil, fmt.Errorf("data source %q requires DATA_SOURCE_PATH", kind)
+		}
+		return NewSQLite(path)
+	default:
+		return nil, fmt.Errorf("unknown data source %q", kind)
+	}
+}
+
+// FromEnv selects the data source from DATA_SOURCE and DATA_SOURCE_PATH and
+// puts the cache configured by CacheFromEnv in front of it.
+func FromEnv() (DataSource, error) {
+	ds, err := New(os.Getenv("DATA_SOURCE"), os.Getenv("DATA_SOURCE_PATH"))
+	if err != nil {
+		return nil, err
+	}
+	return CacheFromEnv(ds), nil
+}
+
+// CacheFromEnv wraps ds in a cache with TTLs from CacheTTLsFromEnv, unless
+// CACHE is set to "off".
+func CacheFromEnv(ds DataSource) DataSource {
+	if os.Getenv("CACHE") == "off" {
+		return ds
+	}
+	return NewCached(ds, CacheTTLsFromEnv())
+}
+
+func usersFromMap(m map[string]string) []models.User {
+	users := make([]models.User, 0, len(m))
+	for id, name := range m {
+		users = append(users, models.User{ID: id, Name: name})
+	}
+	sortUsers(users)
+	return users
+}
+
+// sortUsers orders users by ID; see LessUserID.
+func sortUsers(users []models.User) {
+	sort.Slice(users, func(i, j int) bool {
+		return LessUserID(users[i].ID, users[j].ID)
+	})
+}
+
+// LessUserID orders user IDs numerically when both are numbers and as
+// strings otherwise. Every source lists users in this order.
+func LessUserID(a, b string) bool {
+	na, errA := strconv.Atoi(a)
+	nb, errB := strconv.Atoi(b)
+	if errA == nil && errB == nil {
+		return na < nb
+	}
+	return a < b
+}
+
+// NewerPost orders posts newest first, ties broken by higher ID.
+func NewerPost(a, b models.Post) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.After(b.CreatedAt)
+	}
+	return a.ID > b.ID
+}
+
+// Window bounds creation times to [Since, Until). A zero Since or Until
+// leaves that side open.
+type Window struct {
+	Since time.Time
+	Until time.Time
+}
+
+func (w Window) Contains(t time.Time) bool {
+	return (w.Since.IsZero() || !t.Before(w.Since)) && (w.Until.IsZero() || t.Before(w.Until))
+}
+
+func (w Window) IsZero() bool {
+	return w.Since.IsZero() && w.Until.IsZero()
+}
+
+func (w Window) String() string {
+	return formatBound(w.Since) + "/" + formatBound(w.Until)
+}
+
+func formatBound(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// LessUserPostCount is the leaderboard order: most posts first, ties broken
+// by name and then by ID.
+func LessUserPostCount(a, b models.UserPostCount) bool {
+	if a.PostCount != b.PostCount {
+		return a.PostCount > b.PostCount
+	}
+	if a.User.Name != b.User.Name {
+		return a.User.Name < b.User.Name
+	}
+	return LessUserID(a.User.ID, b.User.ID)
+}
@@ -0,0 +1,30 @@
+package datasource
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"socialify/backend/models"
+)
+
+// fileData is the on-disk layout read by NewFile. Users use the same id to
+// name map as the test server's /users response.
+type fileData struct {
+	Users    map[string]string `json:"users"`
+	Posts    []models.Post     `json:"posts"`
+	Comments []models.Comment  `json:"comments"`
+}
+
+// NewFile loads a JSON snapshot of users, posts and comments from path.
+func NewFile(path string) (*Memory, error) {
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var data fileData
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, err
+	}
+
+	return NewMemory(data.Users, data.Posts, data.Comments), nil
+}
@@ -0,0 +1,105 @@
+package datasource
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"socialify/backend/models"
+	"socialify/backend/upstream"
+	"socialify/backend/utils"
+	"strconv"
+	"time"
+)
+
+// DefaultCallTimeout bounds a single upstream call when the caller's context
+// allows longer.
+const DefaultCallTimeout = 10 * time.Second
+
+// HTTP reads from the remote test server.
+type HTTP struct {
+	BaseURL     string
+	Tokens      *utils.TokenManager
+	Client      *http.Client
+	CallTimeout time.Duration
+}
+
+// NewHTTP returns a source for the test server at baseURL. Requests are
+// authorized with tokens from tokens, or sent bare if it is nil.
+func NewHTTP(baseURL string, tokens *utils.TokenManager) *HTTP {
+	return &HTTP{
+		BaseURL:     baseURL,
+		Tokens:      tokens,
+		Client:      &http.Client{Transport: upstream.Default},
+		CallTimeout: DefaultCallTimeout,
+	}
+}
+
+func (h *HTTP) ListUsers(ctx context.Context) ([]models.User, error) {
+	var resp struct {
+		Users map[string]string `json:"users"`
+	}
+	if err := h.get(ctx, "/users", &resp); err != nil {
+		return nil, err
+	}
+	return usersFromMap(resp.Users), nil
+}
+
+func (h *HTTP) ListPostsByUser(ctx context.Context, userID string) ([]models.Post, error) {
+	var resp struct {
+		Posts []models.Post `json:"posts"`
+	}
+	if err := h.get(ctx, "/users/"+userID+"/posts", &resp); err != nil {
+		return nil, err
+	}
+	return append(make([]models.Post, 0), resp.Posts...), nil
+}
+
+func (h *HTTP) ListCommentsByPost(ctx context.Context, postID int) ([]models.Comment, error) {
+	var resp struct {
+		Comments []models.Comment `json:"comments"`
+	}
+	if err := h.get(ctx, "/posts/"+strconv.Itoa(postID)+"/comments", &resp); err != nil {
+		return nil, err
+	}
+	return append(make([]models.Comment, 0), resp.Comments...), nil
+}
+
+func (h *HTTP) get(ctx context.Context, path string, out interface{}) error {
+	if h.CallTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.CallTimeout)
+		defer cancel()
+	}
+
+	op := "GET " + path
+	req, err := http.NewRequestWithContext(ctx, "GET", h.BaseURL+path, nil)
+	if err != nil {
+		return err
+	}
+
+	var resp *http.Response
+	if h.Tokens != nil {
+		resp, err = h.Tokens.Do(h.Client, req)
+	} else {
+		resp, err = h.Client.Do(req)
+	}
+	if err != nil {
+		return upstream.FromTransport(op, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return upstream.FromStatus(op, resp.StatusCode)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return upstream.FromTransport(op, err)
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return upstream.Decode(op, err)
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package datasource
+
+import (
+	"context"
+	"socialify/backend/models"
+	"socialify/backend/upstream"
+	"strconv"
+	"time"
+)
+
+var mockUsers = map[string]string{
+	"1":  "John Doe",
+	"2":  "Jane Doe",
+	"3":  "Alice Smith",
+	"4":  "Bob Johnson",
+	"5":  "Charlie Brown",
+	"6":  "Diana White",
+	"7":  "Edward Davis",
+	"8":  "Fiona Miller",
+	"9":  "George Wilson",
+	"10": "Helen Moore",
+}
+
+var mockPosts = []models.Post{
+	{ID: 246, UserID: "1", Content: "Post about ant", CreatedAt: at("2025-03-04T07:45:00Z")},
+	{ID: 161, UserID: "1", Content: "Post about elephant", CreatedAt: at("2025-03-02T08:05:00Z")},
+	{ID: 150, UserID: "1", Content: "Post about ocean", CreatedAt: at("2025-03-01T09:15:00Z")},
+	{ID: 370, UserID: "1", Content: "Post about monkey", CreatedAt: at("2025-03-07T10:00:00Z")},
+	{ID: 344, UserID: "1", Content: "Post about ocean", CreatedAt: at("2025-03-06T16:20:00Z")},
+	{ID: 952, UserID: "1", Content: "Post about zebra", CreatedAt: at("2025-03-13T09:20:00Z")},
+	{ID: 647, UserID: "1", Content: "Post about igloo", CreatedAt: at("2025-03-11T08:30:00Z")},
+	{ID: 421, UserID: "1", Content: "Post about house", CreatedAt: at("2025-03-08T09:50:00Z")},
+	{ID: 890, UserID: "1", Content: "Post about bat", CreatedAt: at("2025-03-14T17:40:00Z")},
+	{ID: 461, UserID: "1", Content: "Post about umbrella", CreatedAt: at("2025-03-09T14:05:00Z")},
+	{ID: 247, UserID: "2", Content: "Post about flowers", CreatedAt: at("2025-03-04T12:10:00Z")},
+	{ID: 162, UserID: "2", Content: "Post about gardens", CreatedAt: at("2025-03-02T19:30:00Z")},
+	{ID: 151, UserID: "2", Content: "Post about rivers", CreatedAt: at("2025-03-01T11:40:00Z")},
+	{ID: 371, UserID: "2", Content: "Post about mountains", CreatedAt: at("2025-03-07T13:25:00Z")},
+	{ID: 345, UserID: "3", Content: "Post about hiking", CreatedAt: at("2025-03-06T21:55:00Z")},
+	{ID: 953, UserID: "3", Content: "Post about camping", CreatedAt: at("2025-03-13T22:05:00Z")},
+	{ID: 648, UserID: "4", Content: "Post about cooking", CreatedAt: at("2025-03-11T20:15:00Z")},
+	{ID: 422, UserID: "4", Content: "Post about baking", CreatedAt: at("2025-03-08T18:35:00Z")},
+	{ID: 891, UserID: "5", Content: "Post about music", CreatedAt: at("2025-03-12T12:00:00Z")},
+	{ID: 462, UserID: "5", Content: "Post about art", CreatedAt: at("2025-03-09T15:45:00Z")},
+}
+
+var mockComments = map[int][]models.Comment{
+	150: {
+		{ID: 3893, PostID: 150, UserID: "2", Content: "Old comment", CreatedAt: at("2025-03-01T10:02:00Z")},
+		{ID: 4791, PostID: 150, UserID: "3", Content: "Boring comment", CreatedAt: at("2025-03-12T08:45:00Z")},
+		{ID: 4792, PostID: 150, UserID: "4", Content: "Interesting comment", CreatedAt: at("2025-03-14T19:10:00Z")},
+	},
+	161: {
+		{ID: 3894, PostID: 161, UserID: "2", Content: "Nice post", CreatedAt: at("2025-03-02T09:30:00Z")},
+		{ID: 4793, PostID: 161, UserID: "5", Content: "Great observation", CreatedAt: at("2025-03-13T11:00:00Z")},
+	},
+	246: {
+		{ID: 3895, PostID: 246, UserID: "3", Content: "I agree", CreatedAt: at("2025-03-04T08:20:00Z")},
+	},
+	370: {
+		{ID: 3896, PostID: 370, UserID: "2", Content: "Funny post", CreatedAt: at("2025-03-07T10:45:00Z")},
+		{ID: 4794, PostID: 370, UserID: "5", Content: "LOL", CreatedAt: at("2025-03-14T20:30:00Z")},
+		{ID: 4795, PostID: 370, UserID: "4", Content: "ROFL", CreatedAt: at("2025-03-14T21:05:00Z")},
+	},
+}
+
+// at parses the RFC 3339 timestamps of the demo data.
+func at(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// Memory serves users, posts and comments held in memory.
+type Memory struct {
+	users    []models.User
+	posts    map[string][]models.Post
+	comments map[int][]models.Comment
+	postIDs  map[int]bool
+}
+
+// NewMemory indexes the given data for lookup by user and post.
+func NewMemory(users map[string]string, posts []models.Post, comments []models.Comment) *Memory {
+	m := &Memory{
+		users:    usersFromMap(users),
+		posts:    make(map[string][]models.Post),
+		comments: make(map[int][]models.Comment),
+		postIDs:  make(map[int]bool),
+	}
+	for id := range users {
+		m.posts[id] = make([]models.Post, 0)
+	}
+	for _, post := range posts {
+		m.posts[post.UserID] = append(m.posts[post.UserID], post)
+		m.postIDs[post.ID] = true
+	}
+	for _, comment := range comments {
+		m.comments[comment.PostID] = append(m.comments[comment.PostID], comment)
+	}
+	return m
+}
+
+// NewMock returns the built-in demo data set.
+func NewMock() *Memory {
+	comments := make([]models.Comment, 0)
+	for _, post := range mockPosts {
+		comments = append(comments, mockComments[post.ID]...)
+	}
+	return NewMemory(mockUsers, mockPosts, comments)
+}
+
+func (m *Memory) ListUsers(ctx context.Context) ([]models.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return append([]models.User(nil), m.users...), nil
+}
+
+func (m *Memory) ListPostsByUser(ctx context.Context, userID string) ([]models.Post, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	posts, ok := m.posts[userID]
+	if !ok {
+		return nil, upstream.NotFound("posts of user " + userID)
+	}
+	return append(make([]models.Post, 0), posts...), nil
+}
+
+func (m *Memory) ListCommentsByPost(ctx context.Context, postID int) ([]models.Comment, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if !m.postIDs[postID] {
+		return nil, upstream.NotFound("comments of post " + strconv.Itoa(postID))
+	}
+	return append(make([]models.Comment, 0), m.comments[postID]...), nil
+}
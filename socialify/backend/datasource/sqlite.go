@@ -0,0 +1,255 @@
+package datasource
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"socialify/backend/models"
+	"socialify/backend/upstream"
+	"strconv"
+	"strings"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// SQLite reads from a database with the users/posts/comments schema built by
+// testdata/generate_testdb.go. It also implements Aggregator so the dashboard
+// aggregates are computed by the database.
+type SQLite struct {
+	db *sql.DB
+}
+
+// NewSQLite opens the database at path read-only.
+func NewSQLite(path string) (*SQLite, error) {
+	db, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if err := checkSchema(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("database %s: %w", path, err)
+	}
+	return &SQLite{db: db}, nil
+}
+
+// schema lists the columns read from each table. Columns added after the
+// first version of the schema are checked at open, so an older database
+// fails here rather than on its first query.
+var schema = map[string][]string{
+	"users":    {"id", "name"},
+	"posts":    {"id", "userid", "content", "created_at"},
+	"comments": {"id", "postid", "userid", "content", "created_at"},
+}
+
+func checkSchema(db *sql.DB) error {
+	var missing []string
+	for _, table := range []string{"users", "posts", "comments"} {
+		rows, err := db.Query(`SELECT name FROM pragma_table_info(?)`, table)
+		if err != nil {
+			return err
+		}
+		have := make(map[string]bool)
+		for rows.Next() {
+			var name string
+			if err := rows.Scan(&name); err != nil {
+				rows.Close()
+				return err
+			}
+			have[name] = true
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		for _, column := range schema[table] {
+			if !have[column] {
+				missing = append(missing, table+"."+column)
+			}
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("outdated schema, missing %s: regenerate it with testdata/generate_testdb.go",
+			strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func (s *SQLite) Close() error {
+	return s.db.Close()
+}
+
+func (s *SQLite) ListUsers(ctx context.Context) ([]models.User, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, name FROM users`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]models.User, 0)
+	for rows.Next() {
+		var user models.User
+		if err := rows.Scan(&user.ID, &user.Name); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sortUsers(users)
+	return users, nil
+}
+
+func (s *SQLite) ListPostsByUser(ctx context.Context, userID string) ([]models.Post, error) {
+	if err := s.mustExist(ctx, `SELECT 1 FROM users WHERE id = ?`, userID, "posts of user "+userID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT id, userid, content, created_at FROM posts WHERE userid = ? ORDER BY id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	posts := make([]models.Post, 0)
+	for rows.Next() {
+		var post models.Post
+		if err := rows.Scan(&post.ID, &post.UserID, &post.Content, &post.CreatedAt); err != nil {
+			return nil, err
+		}
+		posts = append(posts, post)
+	}
+	return posts, rows.Err()
+}
+
+func (s *SQLite) ListCommentsByPost(ctx context.Context, postID int) ([]models.Comment, error) {
+	if err := s.mustExist(ctx, `SELECT 1 FROM posts WHERE id = ?`, postID, "comments of post "+strconv.Itoa(postID)); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT id, postid, userid, content, created_at FROM comments WHERE postid = ? ORDER BY id`, postID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := make([]models.Comment, 0)
+	for rows.Next() {
+		var comment models.Comment
+		if err := rows.Scan(&comment.ID, &comment.PostID, &comment.UserID, &comment.Content, &comment.CreatedAt); err != nil {
+			return nil, err
+		}
+		comments = append(comments, comment)
+	}
+	return comments, rows.Err()
+}
+
+// windowArgs returns the bounds of w as query arguments, NULL where open.
+// Times are stored in UTC, so they compare correctly as text.
+func windowArgs(w Window) (since, until interface{}) {
+	if !w.Since.IsZero() {
+		since = w.Since.UTC()
+	}
+	if !w.Until.IsZero() {
+		until = w.Until.UTC()
+	}
+	return since, until
+}
+
+// mustExist returns a not-found error for op unless query yields a row.
+func (s *SQLite) mustExist(ctx context.Context, query string, arg interface{}, op string) error {
+	var one int
+	err := s.db.QueryRowContext(ctx, query, arg).Scan(&one)
+	if err == sql.ErrNoRows {
+		return upstream.NotFound(op)
+	}
+	return err
+}
+
+func (s *SQLite) TopUsersByPostCount(ctx context.Context, limit int) ([]models.UserPostCount, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT u.id, u.name, COUNT(p.id) AS post_count
+		FROM users u
+		LEFT JOIN posts p ON p.userid = u.id
+		GROUP BY u.id, u.name
+		ORDER BY post_count DESC, u.name, CAST(u.id AS INTEGER), u.id
+		LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]models.UserPostCount, 0)
+	for rows.Next() {
+		var upc models.UserPostCount
+		if err := rows.Scan(&upc.User.ID, &upc.User.Name, &upc.PostCount); err != nil {
+			return nil, err
+		}
+		result = append(result, upc)
+	}
+	return result, rows.Err()
+}
+
+func (s *SQLite) LatestPosts(ctx context.Context, limit int, window Window) ([]models.PostWithUser, error) {
+	since, until := windowArgs(window)
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT p.id, p.userid, p.content, p.created_at, COALESCE(u.name, '')
+		FROM posts p
+		LEFT JOIN users u ON u.id = p.userid
+		WHERE (?1 IS NULL OR p.created_at >= ?1) AND (?2 IS NULL OR p.created_at < ?2)
+		ORDER BY p.created_at DESC, p.id DESC
+		LIMIT ?3`, since, until, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]models.PostWithUser, 0)
+	for rows.Next() {
+		var pu models.PostWithUser
+		if err := rows.Scan(&pu.Post.ID, &pu.Post.UserID, &pu.Post.Content, &pu.Post.CreatedAt, &pu.User.Name); err != nil {
+			return nil, err
+		}
+		pu.User.ID = pu.Post.UserID
+		result = append(result, pu)
+	}
+	return result, rows.Err()
+}
+
+func (s *SQLite) TopPostsByCommentCount(ctx context.Context, limit, minComments int, window Window) ([]models.PostWithUser, error) {
+	since, until := windowArgs(window)
+	rows, err := s.db.QueryContext(ctx, `
+		WITH counts AS (
+			SELECT p.id, p.userid, p.content, p.created_at, COUNT(c.id) AS comment_count
+			FROM posts p
+			LEFT JOIN comments c ON c.postid = p.id
+			WHERE (?1 IS NULL OR p.created_at >= ?1) AND (?2 IS NULL OR p.created_at < ?2)
+			GROUP BY p.id, p.userid, p.content, p.created_at
+		)
+		SELECT counts.id, counts.userid, counts.content, counts.created_at, COALESCE(u.name, ''), counts.comment_count
+		FROM counts
+		LEFT JOIN users u ON u.id = counts.userid
+		WHERE counts.comment_count >= ?3
+		ORDER BY counts.comment_count DESC, counts.id
+		LIMIT ?4`, since, until, minComments, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]models.PostWithUser, 0)
+	for rows.Next() {
+		var pu models.PostWithUser
+		if err := rows.Scan(&pu.Post.ID, &pu.Post.UserID, &pu.Post.Content, &pu.Post.CreatedAt, &pu.User.Name, &pu.CommentCount); err != nil {
+			return nil, err
+		}
+		pu.User.ID = pu.Post.UserID
+		result = append(result, pu)
+	}
+	return result, rows.Err()
+}
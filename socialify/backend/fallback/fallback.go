@@ -0,0 +1,116 @@
+package fallback
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCapacity bounds the number of remembered results per store.
+const DefaultCapacity = 256
+
+// Store remembers the last successful result per key so it can be served
+// while the upstream is unavailable. Results must not be modified once
+// remembered.
+type Store struct {
+	capacity int
+
+	mu      sync.Mutex
+	order   *list.List // of *entry, most recently used first
+	entries map[string]*list.Element
+}
+
+type entry struct {
+	key    string
+	result interface{}
+	at     time.Time
+}
+
+func NewStore(capacity int) *Store {
+	return &Store{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// Remember records result as the last good result for key. When the store
+// is full the least recently used result is forgotten.
+func (s *Store) Remember(key string, result interface{}, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if el, ok := s.entries[key]; ok {
+		*el.Value.(*entry) = entry{key: key, result: result, at: now}
+		s.order.MoveToFront(el)
+		return
+	}
+	s.entries[key] = s.order.PushFront(&entry{key: key, result: result, at: now})
+	if s.order.Len() > s.capacity {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.entries, oldest.Value.(*entry).key)
+	}
+}
+
+// Last returns the last good result for key and when it was remembered, or
+// false if there is none.
+func (s *Store) Last(key string) (interface{}, time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.entries[key]
+	if !ok {
+		return nil, time.Time{}, false
+	}
+	s.order.MoveToFront(el)
+	e := el.Value.(*entry)
+	return e.result, e.at, true
+}
+
+// Len returns the number of remembered results.
+func (s *Store) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.order.Len()
+}
+
+// Group keeps a separate Store per endpoint, so that requests to one
+// endpoint cannot evict the results of another. The endpoint is the part of
+// the key before the first ':'.
+type Group struct {
+	capacity int
+
+	mu     sync.Mutex
+	stores map[string]*Store
+}
+
+// NewGroup returns a group whose stores each hold capacity results.
+func NewGroup(capacity int) *Group {
+	return &Group{capacity: capacity, stores: make(map[string]*Store)}
+}
+
+func (g *Group) Remember(key string, result interface{}, now time.Time) {
+	g.store(key).Remember(key, result, now)
+}
+
+func (g *Group) Last(key string) (interface{}, time.Time, bool) {
+	return g.store(key).Last(key)
+}
+
+func (g *Group) store(key string) *Store {
+	endpoint := key
+	if i := strings.IndexByte(key, ':'); i >= 0 {
+		endpoint = key[:i]
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	s, ok := g.stores[endpoint]
+	if !ok {
+		s = NewStore(g.capacity)
+		g.stores[endpoint] = s
+	}
+	return s
+}
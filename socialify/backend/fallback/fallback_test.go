@@ -0,0 +1,58 @@
+package fallback
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+var t0 = time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+
+func TestStoreEvictsLeastRecentlyUsed(t *testing.T) {
+	s := NewStore(3)
+	s.Remember("a", 1, t0)
+	s.Remember("b", 2, t0.Add(time.Second))
+	s.Remember("c", 3, t0.Add(2*time.Second))
+
+	// Reading a and updating b makes c the least recently used.
+	s.Last("a")
+	s.Remember("b", 20, t0.Add(3*time.Second))
+	s.Remember("d", 4, t0.Add(4*time.Second))
+
+	if _, _, ok := s.Last("c"); ok {
+		t.Error("c was kept")
+	}
+	for key, want := range map[string]int{"a": 1, "b": 20, "d": 4} {
+		got, _, ok := s.Last(key)
+		if !ok || got != want {
+			t.Errorf("%s = %v, %v, want %d", key, got, ok, want)
+		}
+	}
+	if _, at, _ := s.Last("b"); !at.Equal(t0.Add(3 * time.Second)) {
+		t.Errorf("b remembered at %v", at)
+	}
+	if s.Len() != 3 {
+		t.Errorf("len = %d, want 3", s.Len())
+	}
+}
+
+func TestGroupSeparatesEndpoints(t *testing.T) {
+	g := NewGroup(2)
+	g.Remember("topUsers:posts:10", "top", t0)
+	for i := 0; i < 100; i++ {
+		g.Remember(fmt.Sprintf("search:q%d", i), i, t0)
+	}
+
+	if got, _, ok := g.Last("topUsers:posts:10"); !ok || got != "top" {
+		t.Errorf("topUsers result evicted by searches: %v, %v", got, ok)
+	}
+	if _, _, ok := g.Last("search:q0"); ok {
+		t.Error("oldest search was kept")
+	}
+	if got, _, ok := g.Last("search:q99"); !ok || got != 99 {
+		t.Errorf("latest search = %v, %v", got, ok)
+	}
+	if n := g.store("search:").Len(); n != 2 {
+		t.Errorf("search store holds %d results, want 2", n)
+	}
+}
@@ -0,0 +1,143 @@
+package fetch
+
+import (
+	"context"
+	"os"
+	"socialify/backend/datasource"
+	"socialify/backend/models"
+	"socialify/backend/sentiment"
+	"strconv"
+	"sync"
+)
+
+// DefaultLimit is the number of concurrent upstream calls used when
+// FETCH_CONCURRENCY is unset or invalid.
+const DefaultLimit = 8
+
+// LimitFromEnv reads the concurrency limit from FETCH_CONCURRENCY.
+func LimitFromEnv() int {
+	if n, err := strconv.Atoi(os.Getenv("FETCH_CONCURRENCY")); err == nil && n > 0 {
+		return n
+	}
+	return DefaultLimit
+}
+
+// Map calls fn for every item with at most limit calls in flight and returns
+// the results in the order of items. The first error cancels the context
+// passed to the remaining calls, stops dispatching new ones and is returned.
+func Map[T, R any](ctx context.Context, limit int, items []T, fn func(context.Context, T) (R, error)) ([]R, error) {
+	if limit < 1 {
+		limit = 1
+	}
+	if limit > len(items) {
+		limit = len(items)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]R, len(items))
+	var (
+		once     sync.Once
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	fail := func(err error) {
+		once.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	indexes := make(chan int)
+	for w := 0; w < limit; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if ctx.Err() != nil {
+					continue
+				}
+				r, err := fn(ctx, items[i])
+				if err != nil {
+					fail(err)
+					continue
+				}
+				results[i] = r
+			}
+		}()
+	}
+
+dispatch:
+	for i := range items {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// Orchestrator fans the per-user and per-post reads of a data source out
+// over a bounded number of concurrent calls.
+type Orchestrator struct {
+	Source datasource.DataSource
+	Limit  int
+}
+
+func New(source datasource.DataSource, limit int) *Orchestrator {
+	return &Orchestrator{Source: source, Limit: limit}
+}
+
+// PostsByUser lists the posts of every user. The i-th result belongs to
+// users[i].
+func (o *Orchestrator) PostsByUser(ctx context.Context, users []models.User) ([][]models.Post, error) {
+	return Map(ctx, o.Limit, users, func(ctx context.Context, user models.User) ([]models.Post, error) {
+		return o.Source.ListPostsByUser(ctx, user.ID)
+	})
+}
+
+// Comments lists the comments on a post with their sentiment scored.
+func (o *Orchestrator) Comments(ctx context.Context, postID int) ([]models.Comment, error) {
+	comments, err := o.Source.ListCommentsByPost(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The source may share its slice with a cache, so score a copy.
+	scored := make([]models.Comment, len(comments))
+	for i, comment := range comments {
+		comment.Sentiment = sentiment.Score(comment.Content)
+		scored[i] = comment
+	}
+	return scored, nil
+}
+
+// CommentsByPost lists the comments on every post. The i-th result belongs
+// to posts[i].
+func (o *Orchestrator) CommentsByPost(ctx context.Context, posts []models.Post) ([][]models.Comment, error) {
+	return Map(ctx, o.Limit, posts, func(ctx context.Context, post models.Post) ([]models.Comment, error) {
+		return o.Comments(ctx, post.ID)
+	})
+}
+
+// CommentCounts counts the comments on every post, in the order of posts.
+func (o *Orchestrator) CommentCounts(ctx context.Context, posts []models.Post) ([]models.PostCommentCount, error) {
+	return Map(ctx, o.Limit, posts, func(ctx context.Context, post models.Post) (models.PostCommentCount, error) {
+		comments, err := o.Source.ListCommentsByPost(ctx, post.ID)
+		if err != nil {
+			return models.PostCommentCount{}, err
+		}
+		return models.PostCommentCount{Post: post, CommentCount: len(comments)}, nil
+	})
+}
@@ -0,0 +1,177 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"socialify/backend/datasource"
+	"socialify/backend/models"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func sequence(n int) []int {
+	items := make([]int, n)
+	for i := range items {
+		items[i] = i
+	}
+	return items
+}
+
+func TestMapKeepsOrder(t *testing.T) {
+	for _, limit := range []int{0, 1, 3, 8, 100} {
+		items := sequence(50)
+		got, err := Map(context.Background(), limit, items, func(ctx context.Context, i int) (int, error) {
+			time.Sleep(time.Duration(rand.Intn(3)) * time.Millisecond)
+			return i * i, nil
+		})
+		if err != nil {
+			t.Fatalf("limit %d: %v", limit, err)
+		}
+		if len(got) != len(items) {
+			t.Fatalf("limit %d: got %d results, want %d", limit, len(got), len(items))
+		}
+		for i, r := range got {
+			if r != i*i {
+				t.Fatalf("limit %d: result %d = %d, want %d", limit, i, r, i*i)
+			}
+		}
+	}
+}
+
+func TestMapEmpty(t *testing.T) {
+	got, err := Map(context.Background(), 4, nil, func(ctx context.Context, i int) (int, error) {
+		t.Fatal("fn called for no items")
+		return 0, nil
+	})
+	if err != nil || len(got) != 0 {
+		t.Fatalf("got %v, %v", got, err)
+	}
+}
+
+func TestMapRespectsLimit(t *testing.T) {
+	const limit = 4
+	var inFlight, peak int32
+	_, err := Map(context.Background(), limit, sequence(40), func(ctx context.Context, i int) (int, error) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(2 * time.Millisecond)
+		return i, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if peak > limit {
+		t.Errorf("%d calls in flight, limit %d", peak, limit)
+	}
+	if peak < limit {
+		t.Errorf("only %d calls in flight, want %d", peak, limit)
+	}
+}
+
+func TestMapStopsOnFirstError(t *testing.T) {
+	boom := errors.New("boom")
+	var started, cancelled int32
+	got, err := Map(context.Background(), 4, sequence(100), func(ctx context.Context, i int) (int, error) {
+		atomic.AddInt32(&started, 1)
+		if i == 0 {
+			return 0, boom
+		}
+		select {
+		case <-ctx.Done():
+			atomic.AddInt32(&cancelled, 1)
+			return 0, ctx.Err()
+		case <-time.After(time.Second):
+			return i, nil
+		}
+	})
+	if !errors.Is(err, boom) {
+		t.Fatalf("got error %v, want %v", err, boom)
+	}
+	if got != nil {
+		t.Errorf("got results %v alongside an error", got)
+	}
+	// The calls already running are cancelled and no others start.
+	if n := atomic.LoadInt32(&started); n > 4 {
+		t.Errorf("%d calls started after the first error, want at most 4", n)
+	}
+	if n := atomic.LoadInt32(&cancelled); n != atomic.LoadInt32(&started)-1 {
+		t.Errorf("%d of %d other calls cancelled", n, atomic.LoadInt32(&started)-1)
+	}
+}
+
+func TestMapParentCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := Map(ctx, 2, sequence(10), func(ctx context.Context, i int) (int, error) {
+		return i, nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want %v", err, context.Canceled)
+	}
+}
+
+// slowSource answers after a random delay so that calls finish out of order.
+type slowSource struct {
+	datasource.DataSource
+}
+
+func (s slowSource) ListPostsByUser(ctx context.Context, userID string) ([]models.Post, error) {
+	time.Sleep(time.Duration(rand.Intn(3)) * time.Millisecond)
+	return s.DataSource.ListPostsByUser(ctx, userID)
+}
+
+func (s slowSource) ListCommentsByPost(ctx context.Context, postID int) ([]models.Comment, error) {
+	time.Sleep(time.Duration(rand.Intn(3)) * time.Millisecond)
+	return s.DataSource.ListCommentsByPost(ctx, postID)
+}
+
+func TestOrchestratorOrder(t *testing.T) {
+	ctx := context.Background()
+	o := New(slowSource{datasource.NewMock()}, 3)
+
+	users, err := o.Source.ListUsers(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	postsByUser, err := o.PostsByUser(ctx, users)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var posts []models.Post
+	for i, userPosts := range postsByUser {
+		for _, post := range userPosts {
+			if post.UserID != users[i].ID {
+				t.Fatalf("post %d of user %s under user %s", post.ID, post.UserID, users[i].ID)
+			}
+		}
+		posts = append(posts, userPosts...)
+	}
+
+	commentsByPost, err := o.CommentsByPost(ctx, posts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts, err := o.CommentCounts(ctx, posts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, comments := range commentsByPost {
+		for _, comment := range comments {
+			if comment.PostID != posts[i].ID {
+				t.Fatalf("comment %d on post %d under post %d", comment.ID, comment.PostID, posts[i].ID)
+			}
+		}
+		if counts[i].Post.ID != posts[i].ID || counts[i].CommentCount != len(comments) {
+			t.Fatalf("count %d is %d for post %d, want %d for post %d",
+				i, counts[i].CommentCount, counts[i].Post.ID, len(comments), posts[i].ID)
+		}
+	}
+}
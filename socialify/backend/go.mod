@@ -2,10 +2,14 @@ module socialify/backend
 
 go 1.18
 
+require (
+	github.com/gin-gonic/gin v1.7.7
+	github.com/mattn/go-sqlite3 v1.14.24
+)
+
 require (
 	github.com/gin-contrib/cors v1.3.1 // indirect
 	github.com/gin-contrib/sse v0.1.0 // indirect
-	github.com/gin-gonic/gin v1.7.7 // indirect
 	github.com/go-playground/locales v0.13.0 // indirect
 	github.com/go-playground/universal-translator v0.17.0 // indirect
 	github.com/go-playground/validator/v10 v10.4.1 // indirect
@@ -0,0 +1,249 @@
+package graph
+
+import (
+	"math"
+	"socialify/backend/models"
+	"sort"
+)
+
+// Graph is a directed graph of users. An edge from one user to another is
+// weighted by the number of comments the first wrote on the second's posts.
+// Users commenting on their own posts are not recorded.
+type Graph struct {
+	nodes map[string]bool
+	out   map[string]map[string]int
+	in    map[string]map[string]int
+}
+
+func New() *Graph {
+	return &Graph{
+		nodes: make(map[string]bool),
+		out:   make(map[string]map[string]int),
+		in:    make(map[string]map[string]int),
+	}
+}
+
+// Build adds every user, and an edge for every comment whose commenter is
+// known, from the commenter to the author of the post.
+func Build(users []models.User, posts []models.Post, comments []models.Comment) *Graph {
+	g := New()
+	for _, user := range users {
+		g.AddNode(user.ID)
+	}
+
+	authors := make(map[int]string, len(posts))
+	for _, post := range posts {
+		authors[post.ID] = post.UserID
+	}
+	for _, comment := range comments {
+		if author, ok := authors[comment.PostID]; ok && comment.UserID != "" {
+			g.AddEdge(comment.UserID, author, 1)
+		}
+	}
+	return g
+}
+
+func (g *Graph) AddNode(id string) {
+	g.nodes[id] = true
+}
+
+// AddEdge adds weight to the edge from one user to another, adding the
+// users as needed. Self-loops are ignored.
+func (g *Graph) AddEdge(from, to string, weight int) {
+	if from == to {
+		return
+	}
+	g.AddNode(from)
+	g.AddNode(to)
+	if g.out[from] == nil {
+		g.out[from] = make(map[string]int)
+	}
+	if g.in[to] == nil {
+		g.in[to] = make(map[string]int)
+	}
+	g.out[from][to] += weight
+	g.in[to][from] += weight
+}
+
+// Nodes returns the users in the graph in ID order.
+func (g *Graph) Nodes() []string {
+	nodes := make([]string, 0, len(g.nodes))
+	for id := range g.nodes {
+		nodes = append(nodes, id)
+	}
+	sort.Strings(nodes)
+	return nodes
+}
+
+// Weight returns the weight of the edge from one user to another, 0 if
+// there is none.
+func (g *Graph) Weight(from, to string) int {
+	return g.out[from][to]
+}
+
+// Out returns the users id has commented on, with the edge weights. The
+// map must not be modified.
+func (g *Graph) Out(id string) map[string]int {
+	return g.out[id]
+}
+
+// In returns the users who commented on id, with the edge weights. The map
+// must not be modified.
+func (g *Graph) In(id string) map[string]int {
+	return g.in[id]
+}
+
+// Reciprocity returns the fraction of the users id has commented on who
+// have commented on id in return, or 0 if id has commented on no one.
+func (g *Graph) Reciprocity(id string) float64 {
+	if len(g.out[id]) == 0 {
+		return 0
+	}
+	mutual := 0
+	for to := range g.out[id] {
+		if g.out[to][id] > 0 {
+			mutual++
+		}
+	}
+	return float64(mutual) / float64(len(g.out[id]))
+}
+
+// OverallReciprocity returns the fraction of all edges whose reverse edge
+// also exists, or 0 if there are no edges.
+func (g *Graph) OverallReciprocity() float64 {
+	edges, mutual := 0, 0
+	for from, out := range g.out {
+		for to := range out {
+			edges++
+			if g.out[to][from] > 0 {
+				mutual++
+			}
+		}
+	}
+	if edges == 0 {
+		return 0
+	}
+	return float64(mutual) / float64(edges)
+}
+
+// Pair is two users who have commented on each other's posts. A sorts
+// before B.
+type Pair struct {
+	A, B string
+	AToB int
+	BToA int
+}
+
+// Mutual returns every pair of users with edges in both directions, ordered
+// by A, then B.
+func (g *Graph) Mutual() []Pair {
+	pairs := make([]Pair, 0)
+	for from, out := range g.out {
+		for to, weight := range out {
+			if from < to && g.out[to][from] > 0 {
+				pairs = append(pairs, Pair{A: from, B: to, AToB: weight, BToA: g.out[to][from]})
+			}
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].A != pairs[j].A {
+			return pairs[i].A < pairs[j].A
+		}
+		return pairs[i].B < pairs[j].B
+	})
+	return pairs
+}
+
+// PageRank limits. The iteration stops once the scores change by less than
+// pageRankTolerance in total.
+const (
+	pageRankTolerance     = 1e-12
+	pageRankMaxIterations = 200
+)
+
+// PageRank scores every user by weighted PageRank; the scores sum to 1. A
+// user passes the damping fraction of their score on to the users they
+// commented on, in proportion to the comments, and the rest is spread over
+// everyone, as is the whole score of users who commented on no one.
+//
+// The iteration starts from the scores in start, where it has them, so
+// passing the previous result after a small change to the graph converges
+// in a few iterations. PageRank also returns how many it took.
+func (g *Graph) PageRank(damping float64, start map[string]float64) (map[string]float64, int) {
+	nodes := g.Nodes()
+	n := len(nodes)
+	if n == 0 {
+		return map[string]float64{}, 0
+	}
+
+	index := make(map[string]int, n)
+	for i, id := range nodes {
+		index[id] = i
+	}
+
+	// Links into each node, ordered by source so that sums are computed in
+	// the same order every time.
+	type link struct {
+		from   int
+		weight float64
+	}
+	in := make([][]link, n)
+	outWeight := make([]float64, n)
+	for i, id := range nodes {
+		for to, weight := range g.out[id] {
+			in[index[to]] = append(in[index[to]], link{from: i, weight: float64(weight)})
+			outWeight[i] += float64(weight)
+		}
+	}
+	for _, links := range in {
+		sort.Slice(links, func(a, b int) bool { return links[a].from < links[b].from })
+	}
+
+	rank := make([]float64, n)
+	total := 0.0
+	for i, id := range nodes {
+		if score, ok := start[id]; ok && score > 0 {
+			rank[i] = score
+		} else {
+			rank[i] = 1 / float64(n)
+		}
+		total += rank[i]
+	}
+	for i := range rank {
+		rank[i] /= total
+	}
+
+	next := make([]float64, n)
+	iterations := 0
+	for iterations < pageRankMaxIterations {
+		iterations++
+
+		dangling := 0.0
+		for i := range nodes {
+			if outWeight[i] == 0 {
+				dangling += rank[i]
+			}
+		}
+		base := (1-damping)/float64(n) + damping*dangling/float64(n)
+
+		change := 0.0
+		for i := range nodes {
+			sum := 0.0
+			for _, l := range in[i] {
+				sum += rank[l.from] * l.weight / outWeight[l.from]
+			}
+			next[i] = base + damping*sum
+			change += math.Abs(next[i] - rank[i])
+		}
+		rank, next = next, rank
+		if change < pageRankTolerance {
+			break
+		}
+	}
+
+	scores := make(map[string]float64, n)
+	for i, id := range nodes {
+		scores[id] = rank[i]
+	}
+	return scores, iterations
+}
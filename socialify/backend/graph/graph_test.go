@@ -0,0 +1,161 @@
+package graph
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+const epsilon = 1e-9
+
+type edge struct {
+	from, to string
+	weight   int
+}
+
+func build(nodes []string, edges []edge) *Graph {
+	g := New()
+	for _, id := range nodes {
+		g.AddNode(id)
+	}
+	for _, e := range edges {
+		g.AddEdge(e.from, e.to, e.weight)
+	}
+	return g
+}
+
+// testGraphs are graphs with and without dangling users.
+var testGraphs = map[string]*Graph{
+	"cycle":    build(nil, []edge{{"a", "b", 1}, {"b", "c", 1}, {"c", "a", 1}}),
+	"star":     build(nil, []edge{{"a", "hub", 1}, {"b", "hub", 1}, {"c", "hub", 2}}),
+	"dangling": build([]string{"lonely"}, []edge{{"a", "b", 1}}),
+	"weighted": build(nil, []edge{{"a", "b", 3}, {"a", "c", 1}, {"b", "a", 1}, {"c", "a", 2}, {"c", "d", 1}}),
+	"no edges": build([]string{"a", "b", "c", "d"}, nil),
+}
+
+func TestPageRankSumsToOne(t *testing.T) {
+	for name, g := range testGraphs {
+		for _, damping := range []float64{0.01, 0.5, 0.85, 0.99} {
+			scores, _ := g.PageRank(damping, nil)
+			if len(scores) != len(g.Nodes()) {
+				t.Errorf("%s, damping %v: %d scores for %d users", name, damping, len(scores), len(g.Nodes()))
+			}
+			sum := 0.0
+			for _, s := range scores {
+				sum += s
+			}
+			if math.Abs(sum-1) > epsilon {
+				t.Errorf("%s, damping %v: scores sum to %v", name, damping, sum)
+			}
+		}
+	}
+}
+
+func TestPageRankDampingBounds(t *testing.T) {
+	for name, g := range testGraphs {
+		n := float64(len(g.Nodes()))
+		for _, damping := range []float64{0.01, 0.5, 0.85, 0.99} {
+			scores, _ := g.PageRank(damping, nil)
+			// Everyone gets at least the share that is spread over
+			// everyone, and no one more than that plus everything passed
+			// on.
+			low, high := (1-damping)/n, (1-damping)/n+damping
+			for id, s := range scores {
+				if s < low-epsilon || s > high+epsilon {
+					t.Errorf("%s, damping %v: %s scores %v, outside [%v, %v]", name, damping, id, s, low, high)
+				}
+			}
+		}
+	}
+
+	// With little damping the scores stay close to uniform.
+	scores, _ := testGraphs["star"].PageRank(0.01, nil)
+	for id, s := range scores {
+		if math.Abs(s-0.25) > 0.01 {
+			t.Errorf("damping 0.01: %s scores %v, want about 0.25", id, s)
+		}
+	}
+}
+
+func TestPageRankScores(t *testing.T) {
+	tests := []struct {
+		graph string
+		check func(s map[string]float64) error
+	}{
+		{"cycle", func(s map[string]float64) error {
+			for id, v := range s {
+				if math.Abs(v-1.0/3) > epsilon {
+					return fmt.Errorf("%s = %v, want 1/3", id, v)
+				}
+			}
+			return nil
+		}},
+		{"no edges", func(s map[string]float64) error {
+			for id, v := range s {
+				if math.Abs(v-0.25) > epsilon {
+					return fmt.Errorf("%s = %v, want 1/4", id, v)
+				}
+			}
+			return nil
+		}},
+		{"star", func(s map[string]float64) error {
+			if s["hub"] <= s["a"] || math.Abs(s["a"]-s["b"]) > epsilon {
+				return fmt.Errorf("got %v, want the hub first and a and b equal", s)
+			}
+			return nil
+		}},
+		{"dangling", func(s map[string]float64) error {
+			// b and lonely commented on no one; their score is spread over
+			// everyone, so lonely ends up with as much as a.
+			if math.Abs(s["lonely"]-s["a"]) > epsilon || s["b"] <= s["a"] {
+				return fmt.Errorf("got %v, want b first and a and lonely equal", s)
+			}
+			return nil
+		}},
+		{"weighted", func(s map[string]float64) error {
+			if s["b"] <= s["c"] {
+				return fmt.Errorf("got %v, want b, with 3 comments from a, above c with 1", s)
+			}
+			return nil
+		}},
+	}
+	for _, tt := range tests {
+		scores, _ := testGraphs[tt.graph].PageRank(0.85, nil)
+		if err := tt.check(scores); err != nil {
+			t.Errorf("%s: %v", tt.graph, err)
+		}
+	}
+}
+
+func TestPageRankWarmStart(t *testing.T) {
+	// Most users comment on a hub, which is far from the uniform scores a
+	// cold start begins with.
+	g := New()
+	for i := 1; i <= 20; i++ {
+		g.AddEdge(fmt.Sprint(i), "hub", 5)
+		g.AddEdge(fmt.Sprint(i), fmt.Sprint(i%20+1), 1)
+	}
+	g.AddEdge("hub", "1", 1)
+	previous, _ := g.PageRank(0.85, nil)
+
+	// A new comment changes the scores a little.
+	g.AddEdge("7", "3", 1)
+	cold, coldIterations := g.PageRank(0.85, nil)
+	warm, warmIterations := g.PageRank(0.85, previous)
+
+	if warmIterations >= coldIterations {
+		t.Errorf("warm start took %d iterations, cold start %d", warmIterations, coldIterations)
+	}
+	for id, want := range cold {
+		if math.Abs(warm[id]-want) > epsilon {
+			t.Errorf("%s: warm start gives %v, cold start %v", id, warm[id], want)
+		}
+	}
+}
+
+func TestPageRankEmpty(t *testing.T) {
+	scores, iterations := New().PageRank(0.85, nil)
+	if len(scores) != 0 || iterations != 0 {
+		t.Errorf("got %v after %d iterations", scores, iterations)
+	}
+}
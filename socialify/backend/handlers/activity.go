@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"net/http"
+	"socialify/backend/analytics"
+
+	"github.com/gin-gonic/gin"
+)
+
+func GetActivity(c *gin.Context) {
+	q, err := analytics.ParseActivityQuery(c.Request.URL.Query())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := service.Activity(c.Request.Context(), q)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+	"socialify/backend/datasource"
+	"socialify/backend/upstream"
+
+	"github.com/gin-gonic/gin"
+)
+
+func GetCacheStats(c *gin.Context) {
+	cache, ok := datasource.CacheOf(source)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "cache disabled"})
+		return
+	}
+
+	c.JSON(http.StatusOK, cache.Stats())
+}
+
+// InvalidateCache drops cached upstream reads. The optional resource and id
+// query parameters narrow what is dropped; see datasource.Cached.Invalidate.
+func InvalidateCache(c *gin.Context) {
+	cache, ok := datasource.CacheOf(source)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "cache disabled"})
+		return
+	}
+
+	if err := cache.Invalidate(c.Query("resource"), c.Query("id")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetUpstreamState reports the retry and circuit breaker state of every
+// upstream endpoint called so far.
+func GetUpstreamState(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"endpoints": upstream.Default.State()})
+}
@@ -2,29 +2,15 @@ package handlers
 
 import (
 	"net/http"
-	"os"
+	"socialify/backend/config"
 	"socialify/backend/models"
 	"socialify/backend/utils"
 
 	"github.com/gin-gonic/gin"
 )
 
-var (
-	clientID     string
-	clientSecret string
-	companyName  string
-	ownerName    string
-	ownerEmail   string
-	rollNo       string
-)
-
 func init() {
-	clientID = os.Getenv("CLIENT_ID")
-	clientSecret = os.Getenv("CLIENT_SECRET")
-	companyName = os.Getenv("COMPANY_NAME")
-	ownerName = os.Getenv("OWNER_NAME")
-	ownerEmail = os.Getenv("OWNER_EMAIL")
-	rollNo = os.Getenv("ROLL_NO")
+	utils.SetCredentials(config.Credentials())
 }
 
 func Register(c *gin.Context) {
@@ -36,7 +22,7 @@ func Register(c *gin.Context) {
 
 	resp, err := utils.RegisterWithTestServer(req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -50,18 +36,11 @@ func Auth(c *gin.Context) {
 		return
 	}
 
-	resp, err := utils.GetAuthToken(req)
+	resp, err := utils.GetAuthToken(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
-	clientID = req.ClientID
-	clientSecret = req.ClientSecret
-	companyName = req.CompanyName
-	ownerName = req.OwnerName
-	ownerEmail = req.OwnerEmail
-	rollNo = req.RollNo
-
 	c.JSON(http.StatusOK, resp)
 }
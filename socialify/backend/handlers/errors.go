@@ -0,0 +1,16 @@
+package handlers
+
+import (
+	"log"
+	"socialify/backend/upstream"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respondError sends the client-facing form of err. The full error is only
+// logged.
+func respondError(c *gin.Context, err error) {
+	p := upstream.Describe(err)
+	log.Printf("%s %s: %d %s: %v", c.Request.Method, c.Request.URL.Path, p.Status, p.Code, err)
+	c.JSON(p.Status, p)
+}
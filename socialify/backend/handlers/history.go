@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"net/http"
+	"socialify/backend/analytics"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func GetUserRankHistory(c *gin.Context) {
+	q, err := analytics.ParseHistoryQuery(c.Request.URL.Query())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := service.UserRankHistory(c.Param("userId"), q)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func GetPostRankHistory(c *gin.Context) {
+	postID, err := strconv.Atoi(c.Param("postId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid post id"})
+		return
+	}
+
+	ranking, err := analytics.ParseRanking(c.Request.URL.Query())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := service.PostRankHistory(postID, ranking)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+	"socialify/backend/analytics"
+
+	"github.com/gin-gonic/gin"
+)
+
+func GetUserInteractions(c *gin.Context) {
+	page, err := analytics.ParsePage(c.Request.URL.Query())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := service.UserInteractions(c.Request.Context(), c.Param("userId"), page)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func GetMutualPairs(c *gin.Context) {
+	page, err := analytics.ParsePage(c.Request.URL.Query())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := service.MutualPairs(c.Request.Context(), page)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func GetInfluentialUsers(c *gin.Context) {
+	q, err := analytics.ParseInfluenceQuery(c.Request.URL.Query())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := service.InfluentialUsers(c.Request.Context(), q)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"net/http"
+	"socialify/backend/middleware"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Timeout is middleware.Timeout for gin: the rest of the chain gets a request
+// context that expires after d, so upstream calls made on behalf of the
+// request stop once it is over.
+func Timeout(d time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			c.Request = r
+			c.Next()
+		})
+		middleware.Timeout(d, next).ServeHTTP(c.Writer, c.Request)
+	}
+}
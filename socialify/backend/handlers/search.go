@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"net/http"
+	"socialify/backend/analytics"
+
+	"github.com/gin-gonic/gin"
+)
+
+func Search(c *gin.Context) {
+	q, err := analytics.ParseSearchQuery(c.Request.URL.Query())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := service.Search(c.Request.Context(), q)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
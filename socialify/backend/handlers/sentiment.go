@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"net/http"
+	"socialify/backend/analytics"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func GetSentimentPosts(c *gin.Context) {
+	q, err := analytics.ParseSentimentQuery(c.Request.URL.Query())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := service.SentimentPosts(c.Request.Context(), q)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func GetPostSentiment(c *gin.Context) {
+	postID, err := strconv.Atoi(c.Param("postId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid post id"})
+		return
+	}
+
+	res, err := service.PostSentiment(c.Request.Context(), postID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func GetUserSentiment(c *gin.Context) {
+	res, err := service.UserSentiment(c.Request.Context(), c.Param("userId"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"context"
+	"socialify/backend/analytics"
+	"socialify/backend/datasource"
+	"socialify/backend/fetch"
+	"socialify/backend/history"
+	"socialify/backend/ingest"
+	"time"
+)
+
+var (
+	source  datasource.DataSource = datasource.NewMock()
+	service                       = analytics.New(fetch.New(source, fetch.LimitFromEnv()))
+)
+
+// SetDataSource replaces the data source the handlers read from. It must be
+// called before the router starts serving.
+func SetDataSource(ds datasource.DataSource) {
+	source = ds
+	service.Fetcher = fetch.New(ds, service.Fetcher.Limit)
+}
+
+// SetFetchConcurrency bounds the number of concurrent upstream calls a single
+// request may make.
+func SetFetchConcurrency(limit int) {
+	service.Fetcher = fetch.New(source, limit)
+}
+
+// Fetcher returns the orchestrator the handlers fetch through, for sharing
+// with a background ingest.Worker.
+func Fetcher() *fetch.Orchestrator {
+	return service.Fetcher
+}
+
+// AttachWorker makes the analytics handlers serve from what w pulls once it
+// has completed a refresh. Call it before w runs.
+func AttachWorker(w *ingest.Worker) {
+	service.Attach(w)
+}
+
+// SetHistory makes the leaderboards report rank movement against the
+// snapshots in store and serves rank histories from it.
+func SetHistory(store *history.Store) {
+	service.History = store
+}
+
+// RecordHistory snapshots the leaderboards into the store passed to
+// SetHistory every interval until ctx is done.
+func RecordHistory(ctx context.Context, interval time.Duration) {
+	service.RecordHistory(ctx, interval)
+}
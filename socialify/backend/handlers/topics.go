@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"net/http"
+	"socialify/backend/analytics"
+
+	"github.com/gin-gonic/gin"
+)
+
+func GetTopics(c *gin.Context) {
+	page, err := analytics.ParsePage(c.Request.URL.Query())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := service.Topics(c.Request.Context(), page)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func GetTopicPosts(c *gin.Context) {
+	res, err := service.TopicPosts(c.Request.Context(), c.Param("topic"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func GetUserTopics(c *gin.Context) {
+	res, err := service.UserTopics(c.Request.Context(), c.Param("userId"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
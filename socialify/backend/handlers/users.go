@@ -1,11 +1,8 @@
 package handlers
 
 import (
-	"encoding/json"
 	"net/http"
-	"socialify/backend/models"
-	"socialify/backend/utils"
-	"sort"
+	"socialify/backend/analytics"
 
 	"github.com/gin-gonic/gin"
 )
@@ -15,68 +12,48 @@ type UsersResponse struct {
 }
 
 func GetUsers(c *gin.Context) {
-	body, err := utils.FetchFromTestServer("/users", clientID, clientSecret, companyName, ownerName, ownerEmail, rollNo)
+	users, err := source.ListUsers(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
-	var resp UsersResponse
-	if err := json.Unmarshal(body, &resp); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	resp := UsersResponse{Users: make(map[string]string, len(users))}
+	for _, user := range users {
+		resp.Users[user.ID] = user.Name
 	}
 
 	c.JSON(http.StatusOK, resp)
 }
 
-func GetTopUsers(c *gin.Context) {
-	body, err := utils.FetchFromTestServer("/users", clientID, clientSecret, companyName, ownerName, ownerEmail, rollNo)
+func GetUserStats(c *gin.Context) {
+	ranking, err := analytics.ParseRanking(c.Request.URL.Query())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	var usersResp UsersResponse
-	if err := json.Unmarshal(body, &usersResp); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	res, err := service.UserStats(c.Request.Context(), c.Param("userId"), ranking)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
-	userPostCounts := make([]models.UserPostCount, 0)
-
-	for id, name := range usersResp.Users {
-		postsURL := "/users/" + id + "/posts"
-		postsBody, err := utils.FetchFromTestServer(postsURL, clientID, clientSecret, companyName, ownerName, ownerEmail, rollNo)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-
-		var postsResp struct {
-			Posts []models.Post `json:"posts"`
-		}
-		if err := json.Unmarshal(postsBody, &postsResp); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
+	c.JSON(http.StatusOK, res)
+}
 
-		userPostCounts = append(userPostCounts, models.UserPostCount{
-			User: models.User{
-				ID:   id,
-				Name: name,
-			},
-			PostCount: len(postsResp.Posts),
-		})
+func GetTopUsers(c *gin.Context) {
+	q, err := analytics.ParseTopUsersQuery(c.Request.URL.Query())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	sort.Slice(userPostCounts, func(i, j int) bool {
-		return userPostCounts[i].PostCount > userPostCounts[j].PostCount
-	})
-
-	if len(userPostCounts) > 5 {
-		userPostCounts = userPostCounts[:5]
+	res, err := service.TopUsers(c.Request.Context(), q)
+	if err != nil {
+		respondError(c, err)
+		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"topUsers": userPostCounts})
+	c.JSON(http.StatusOK, res)
 }
@@ -0,0 +1,215 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Defaults for HISTORY_PATH and SNAPSHOT_INTERVAL.
+const (
+	DefaultPath     = "leaderboard_history.jsonl"
+	DefaultInterval = time.Hour
+)
+
+// MaxSnapshots is how many snapshots are kept per leaderboard. Older ones
+// are dropped as new ones are added, and from the file once it holds twice
+// as many snapshots as are kept.
+const MaxSnapshots = 720
+
+// PathFromEnv reads the history file from HISTORY_PATH, DefaultPath when it
+// is unset. "off" keeps snapshots in memory only.
+func PathFromEnv() string {
+	switch v := os.Getenv("HISTORY_PATH"); v {
+	case "":
+		return DefaultPath
+	case "off":
+		return ""
+	default:
+		return v
+	}
+}
+
+// IntervalFromEnv reads how often leaderboards are snapshotted from
+// SNAPSHOT_INTERVAL. Zero disables snapshots.
+func IntervalFromEnv() time.Duration {
+	v := os.Getenv("SNAPSHOT_INTERVAL")
+	if v == "" {
+		return DefaultInterval
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		log.Printf("ignoring invalid SNAPSHOT_INTERVAL %q", v)
+		return DefaultInterval
+	}
+	return d
+}
+
+// Entry is one place on a leaderboard. Entries with the same score are
+// tied.
+type Entry struct {
+	ID    string  `json:"id"`
+	Score float64 `json:"score"`
+}
+
+// Snapshot is a leaderboard as it stood at TakenAt, best first.
+type Snapshot struct {
+	Board   string    `json:"board"`
+	TakenAt time.Time `json:"takenAt"`
+	Entries []Entry   `json:"entries"`
+}
+
+// Store keeps the snapshots of each leaderboard, oldest first, and appends
+// every new one as a JSON line to a file. It is safe for concurrent use.
+type Store struct {
+	path string
+
+	mu     sync.RWMutex
+	boards map[string][]Snapshot
+	// lines counts the snapshots in the file, dropped ones included.
+	lines int
+}
+
+// Open loads the snapshots in the file at path, creating it if needed. An
+// empty path keeps snapshots in memory only.
+func Open(path string) (*Store, error) {
+	s := &Store{path: path, boards: make(map[string][]Snapshot)}
+	if path == "" {
+		return s, nil
+	}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 16<<20)
+	for ; scanner.Scan(); s.lines++ {
+		var snap Snapshot
+		if err := json.Unmarshal(scanner.Bytes(), &snap); err != nil {
+			return nil, fmt.Errorf("history: %s:%d: %w", path, s.lines+1, err)
+		}
+		s.appendLocked(snap)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("history: %s: %w", path, err)
+	}
+
+	if s.lines > s.keptLocked() {
+		if err := s.rewrite(); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Add records snap after the other snapshots of its leaderboard.
+func (s *Store) Add(snap Snapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.path != "" {
+		line, err := json.Marshal(snap)
+		if err != nil {
+			return err
+		}
+		f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(append(line, '\n'))
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return err
+		}
+		s.lines++
+	}
+
+	s.appendLocked(snap)
+	if s.path != "" && s.lines >= 2*s.keptLocked() {
+		return s.rewrite()
+	}
+	return nil
+}
+
+// appendLocked adds snap to its leaderboard, dropping the oldest snapshot
+// beyond MaxSnapshots.
+func (s *Store) appendLocked(snap Snapshot) {
+	snaps := append(s.boards[snap.Board], snap)
+	if len(snaps) > MaxSnapshots {
+		snaps = snaps[len(snaps)-MaxSnapshots:]
+	}
+	s.boards[snap.Board] = snaps
+}
+
+func (s *Store) keptLocked() int {
+	kept := 0
+	for _, snaps := range s.boards {
+		kept += len(snaps)
+	}
+	return kept
+}
+
+// Latest returns the most recent snapshot of board.
+func (s *Store) Latest(board string) (Snapshot, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snaps := s.boards[board]
+	if len(snaps) == 0 {
+		return Snapshot{}, false
+	}
+	return snaps[len(snaps)-1], true
+}
+
+// Snapshots returns the snapshots of board, oldest first.
+func (s *Store) Snapshots(board string) []Snapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]Snapshot(nil), s.boards[board]...)
+}
+
+// rewrite replaces the file with the snapshots held in memory. The caller
+// holds s.mu or has the store to itself.
+func (s *Store) rewrite() error {
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	for _, snaps := range s.boards {
+		for _, snap := range snaps {
+			if err := enc.Encode(snap); err != nil {
+				tmp.Close()
+				return err
+			}
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return err
+	}
+	s.lines = s.keptLocked()
+	return nil
+}
@@ -0,0 +1,94 @@
+package history
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var t0 = time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+
+func snapshot(board string, i int) Snapshot {
+	return Snapshot{Board: board, TakenAt: t0.Add(time.Duration(i) * time.Hour), Entries: []Entry{{ID: "1", Score: float64(i)}}}
+}
+
+func countLines(t *testing.T, path string) int {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	n := 0
+	for scanner := bufio.NewScanner(f); scanner.Scan(); n++ {
+	}
+	return n
+}
+
+func TestStoreKeepsMaxSnapshots(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const added = 3*MaxSnapshots + 10
+	for i := 0; i < added; i++ {
+		if err := s.Add(snapshot("posts", i)); err != nil {
+			t.Fatal(err)
+		}
+		if i%100 == 0 {
+			if err := s.Add(snapshot("comments", i)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	snaps := s.Snapshots("posts")
+	if len(snaps) != MaxSnapshots {
+		t.Fatalf("kept %d snapshots, want %d", len(snaps), MaxSnapshots)
+	}
+	if want := snapshot("posts", added-MaxSnapshots); !snaps[0].TakenAt.Equal(want.TakenAt) {
+		t.Errorf("oldest kept taken at %v, want %v", snaps[0].TakenAt, want.TakenAt)
+	}
+	if latest, _ := s.Latest("posts"); !latest.TakenAt.Equal(snapshot("posts", added-1).TakenAt) {
+		t.Errorf("latest taken at %v", latest.TakenAt)
+	}
+	if n, kept := countLines(t, path), MaxSnapshots+len(s.Snapshots("comments")); n >= 2*kept {
+		t.Errorf("file holds %d snapshots for %d kept", n, kept)
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, board := range []string{"posts", "comments"} {
+		got, want := reopened.Snapshots(board), s.Snapshots(board)
+		if len(got) != len(want) {
+			t.Fatalf("%s: reopened with %d snapshots, want %d", board, len(got), len(want))
+		}
+		for i := range got {
+			if !got[i].TakenAt.Equal(want[i].TakenAt) || !reflect.DeepEqual(got[i].Entries, want[i].Entries) {
+				t.Fatalf("%s: snapshot %d = %+v, want %+v", board, i, got[i], want[i])
+			}
+		}
+	}
+}
+
+func TestStoreInMemory(t *testing.T) {
+	s, err := Open("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < MaxSnapshots+1; i++ {
+		if err := s.Add(snapshot("posts", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := len(s.Snapshots("posts")); n != MaxSnapshots {
+		t.Errorf("kept %d snapshots, want %d", n, MaxSnapshots)
+	}
+}
@@ -0,0 +1,182 @@
+package ingest
+
+import (
+	"socialify/backend/datasource"
+	"socialify/backend/models"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Snapshot is an immutable view of the leaderboards as of one refresh.
+type Snapshot struct {
+	RefreshedAt     time.Time
+	RefreshInterval time.Duration
+	// TopUsers ranks every user in datasource.LessUserPostCount order.
+	TopUsers []models.UserPostCount
+	// LatestPosts lists every post in datasource.NewerPost order.
+	LatestPosts []models.PostWithUser
+	// MostCommented lists every post, most commented first and ties by ID.
+	MostCommented []models.PostWithUser
+}
+
+// Stale reports whether the snapshot has missed at least two refreshes, which
+// happens while the upstream is unavailable.
+func (s *Snapshot) Stale(now time.Time) bool {
+	return now.Sub(s.RefreshedAt) > 2*s.RefreshInterval
+}
+
+// Index holds users, posts and comment counts pulled from the data source and
+// keeps the leaderboards sorted as they change, so each refresh only moves
+// the entries that changed. Readers get the latest Snapshot without locking.
+type Index struct {
+	interval time.Duration
+
+	mu            sync.Mutex
+	users         map[string]models.User
+	postCounts    map[string]int
+	posts         map[int]models.Post
+	commentCounts map[int]int
+	topUsers      []models.UserPostCount
+	latest        []models.Post
+	byComments    []models.PostCommentCount
+
+	snapshot atomic.Value
+}
+
+// NewIndex returns an empty index. interval is reported in snapshots as the
+// refresh interval.
+func NewIndex(interval time.Duration) *Index {
+	return &Index{
+		interval:      interval,
+		users:         make(map[string]models.User),
+		postCounts:    make(map[string]int),
+		posts:         make(map[int]models.Post),
+		commentCounts: make(map[int]int),
+	}
+}
+
+// Snapshot returns the leaderboards from the last refresh, or nil before the
+// first one has completed.
+func (ix *Index) Snapshot() *Snapshot {
+	snap, _ := ix.snapshot.Load().(*Snapshot)
+	return snap
+}
+
+// Apply merges a full pull of the data source into the index. commentCounts
+// has the number of comments on every post in posts.
+func (ix *Index) Apply(users []models.User, posts []models.Post, commentCounts map[int]int, now time.Time) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	postCounts := make(map[string]int, len(users))
+	for _, post := range posts {
+		postCounts[post.UserID]++
+	}
+
+	seenUsers := make(map[string]bool, len(users))
+	for _, user := range users {
+		seenUsers[user.ID] = true
+		old, exists := ix.users[user.ID]
+		oldCount := ix.postCounts[user.ID]
+		if exists && old == user && oldCount == postCounts[user.ID] {
+			continue
+		}
+		if exists {
+			ix.topUsers = remove(ix.topUsers, models.UserPostCount{User: old, PostCount: oldCount}, datasource.LessUserPostCount)
+		}
+		entry := models.UserPostCount{User: user, PostCount: postCounts[user.ID]}
+		ix.topUsers = insert(ix.topUsers, entry, datasource.LessUserPostCount)
+		ix.users[user.ID] = user
+		ix.postCounts[user.ID] = entry.PostCount
+	}
+	for id, old := range ix.users {
+		if !seenUsers[id] {
+			ix.topUsers = remove(ix.topUsers, models.UserPostCount{User: old, PostCount: ix.postCounts[id]}, datasource.LessUserPostCount)
+			delete(ix.users, id)
+			delete(ix.postCounts, id)
+		}
+	}
+
+	seenPosts := make(map[int]bool, len(posts))
+	for _, post := range posts {
+		seenPosts[post.ID] = true
+		old, exists := ix.posts[post.ID]
+		oldCount := ix.commentCounts[post.ID]
+		count := commentCounts[post.ID]
+		if exists && old == post && oldCount == count {
+			continue
+		}
+		if exists {
+			ix.latest = remove(ix.latest, old, datasource.NewerPost)
+			ix.byComments = remove(ix.byComments, models.PostCommentCount{Post: old, CommentCount: oldCount}, morePostComments)
+		}
+		ix.latest = insert(ix.latest, post, datasource.NewerPost)
+		ix.byComments = insert(ix.byComments, models.PostCommentCount{Post: post, CommentCount: count}, morePostComments)
+		ix.posts[post.ID] = post
+		ix.commentCounts[post.ID] = count
+	}
+	for id, old := range ix.posts {
+		if !seenPosts[id] {
+			ix.latest = remove(ix.latest, old, datasource.NewerPost)
+			ix.byComments = remove(ix.byComments, models.PostCommentCount{Post: old, CommentCount: ix.commentCounts[id]}, morePostComments)
+			delete(ix.posts, id)
+			delete(ix.commentCounts, id)
+		}
+	}
+
+	ix.publishLocked(now)
+}
+
+func (ix *Index) publishLocked(now time.Time) {
+	snap := &Snapshot{
+		RefreshedAt:     now,
+		RefreshInterval: ix.interval,
+		TopUsers:        append(make([]models.UserPostCount, 0, len(ix.topUsers)), ix.topUsers...),
+		LatestPosts:     make([]models.PostWithUser, 0, len(ix.latest)),
+		MostCommented:   make([]models.PostWithUser, 0, len(ix.byComments)),
+	}
+	for _, post := range ix.latest {
+		snap.LatestPosts = append(snap.LatestPosts, models.PostWithUser{Post: post, User: ix.author(post)})
+	}
+	for _, pc := range ix.byComments {
+		snap.MostCommented = append(snap.MostCommented, models.PostWithUser{
+			Post:         pc.Post,
+			User:         ix.author(pc.Post),
+			CommentCount: pc.CommentCount,
+		})
+	}
+	ix.snapshot.Store(snap)
+}
+
+func (ix *Index) author(post models.Post) models.User {
+	return models.User{ID: post.UserID, Name: ix.users[post.UserID].Name}
+}
+
+func morePostComments(a, b models.PostCommentCount) bool {
+	if a.CommentCount != b.CommentCount {
+		return a.CommentCount > b.CommentCount
+	}
+	return a.Post.ID < b.Post.ID
+}
+
+// insert adds v to s, which is sorted by less.
+func insert[T any](s []T, v T, less func(a, b T) bool) []T {
+	i := sort.Search(len(s), func(i int) bool { return less(v, s[i]) })
+	var zero T
+	s = append(s, zero)
+	copy(s[i+1:], s[i:])
+	s[i] = v
+	return s
+}
+
+// remove deletes the element equal to v under less from s, which is sorted
+// by less.
+func remove[T any](s []T, v T, less func(a, b T) bool) []T {
+	i := sort.Search(len(s), func(i int) bool { return !less(s[i], v) })
+	if i < len(s) && !less(v, s[i]) {
+		s = append(s[:i], s[i+1:]...)
+	}
+	return s
+}
@@ -0,0 +1,185 @@
+package ingest
+
+import (
+	"reflect"
+	"socialify/backend/datasource"
+	"socialify/backend/models"
+	"sort"
+	"testing"
+	"time"
+)
+
+// state is one full pull as passed to Apply.
+type state struct {
+	users    []models.User
+	posts    []models.Post
+	comments map[int]int
+}
+
+// recompute builds the leaderboards of st from scratch.
+func recompute(st state) *Snapshot {
+	names := make(map[string]string, len(st.users))
+	postCounts := make(map[string]int, len(st.users))
+	for _, user := range st.users {
+		names[user.ID] = user.Name
+	}
+	for _, post := range st.posts {
+		postCounts[post.UserID]++
+	}
+
+	snap := &Snapshot{
+		TopUsers:      make([]models.UserPostCount, 0),
+		LatestPosts:   make([]models.PostWithUser, 0),
+		MostCommented: make([]models.PostWithUser, 0),
+	}
+	for _, user := range st.users {
+		snap.TopUsers = append(snap.TopUsers, models.UserPostCount{User: user, PostCount: postCounts[user.ID]})
+	}
+	sort.Slice(snap.TopUsers, func(i, j int) bool {
+		return datasource.LessUserPostCount(snap.TopUsers[i], snap.TopUsers[j])
+	})
+
+	posts := append([]models.Post(nil), st.posts...)
+	sort.Slice(posts, func(i, j int) bool { return datasource.NewerPost(posts[i], posts[j]) })
+	for _, post := range posts {
+		author := models.User{ID: post.UserID, Name: names[post.UserID]}
+		snap.LatestPosts = append(snap.LatestPosts, models.PostWithUser{Post: post, User: author})
+		snap.MostCommented = append(snap.MostCommented, models.PostWithUser{Post: post, User: author, CommentCount: st.comments[post.ID]})
+	}
+	sort.SliceStable(snap.MostCommented, func(i, j int) bool {
+		a, b := snap.MostCommented[i], snap.MostCommented[j]
+		if a.CommentCount != b.CommentCount {
+			return a.CommentCount > b.CommentCount
+		}
+		return a.Post.ID < b.Post.ID
+	})
+	return snap
+}
+
+var t0 = time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func post(id int, userID string, hour int) models.Post {
+	return models.Post{ID: id, UserID: userID, Content: "post", CreatedAt: t0.Add(time.Duration(hour) * time.Hour)}
+}
+
+var (
+	alice = models.User{ID: "1", Name: "Alice"}
+	bob   = models.User{ID: "2", Name: "Bob"}
+	carol = models.User{ID: "3", Name: "Carol"}
+	dave  = models.User{ID: "10", Name: "Alice"}
+)
+
+var base = state{
+	users:    []models.User{alice, bob, carol},
+	posts:    []models.Post{post(1, "1", 0), post(2, "1", 1), post(3, "2", 2), post(4, "3", 3)},
+	comments: map[int]int{1: 2, 2: 0, 3: 2, 4: 5},
+}
+
+func TestIndexApply(t *testing.T) {
+	tests := []struct {
+		name  string
+		pulls []state
+	}{
+		{"first pull", []state{base}},
+		{"unchanged", []state{base, base}},
+		{"user added", []state{base, {
+			users:    []models.User{alice, bob, carol, dave},
+			posts:    append(append([]models.Post(nil), base.posts...), post(5, "10", 4)),
+			comments: map[int]int{1: 2, 2: 0, 3: 2, 4: 5, 5: 1},
+		}}},
+		{"user removed with their posts", []state{base, {
+			users:    []models.User{alice, carol},
+			posts:    []models.Post{post(1, "1", 0), post(2, "1", 1), post(4, "3", 3)},
+			comments: map[int]int{1: 2, 2: 0, 4: 5},
+		}}},
+		{"user renamed", []state{base, {
+			users:    []models.User{{ID: "1", Name: "Zoe"}, bob, carol},
+			posts:    base.posts,
+			comments: base.comments,
+		}}},
+		{"post added", []state{base, {
+			users:    base.users,
+			posts:    append(append([]models.Post(nil), base.posts...), post(5, "2", -1)),
+			comments: map[int]int{1: 2, 2: 0, 3: 2, 4: 5, 5: 0},
+		}}},
+		{"post removed", []state{base, {
+			users:    base.users,
+			posts:    []models.Post{post(1, "1", 0), post(3, "2", 2), post(4, "3", 3)},
+			comments: map[int]int{1: 2, 3: 2, 4: 5},
+		}}},
+		{"post edited", []state{base, {
+			users:    base.users,
+			posts:    []models.Post{post(1, "1", 0), post(2, "1", 1), {ID: 3, UserID: "2", Content: "edited", CreatedAt: t0.Add(5 * time.Hour)}, post(4, "3", 3)},
+			comments: base.comments,
+		}}},
+		{"comments added and removed", []state{base, {
+			users:    base.users,
+			posts:    base.posts,
+			comments: map[int]int{1: 0, 2: 7, 3: 2, 4: 1},
+		}}},
+		{"everything removed and back", []state{base, {comments: map[int]int{}}, base}},
+		{"several changes in a row", []state{
+			base,
+			{
+				users:    []models.User{alice, bob, carol, dave},
+				posts:    append(append([]models.Post(nil), base.posts...), post(5, "10", 4), post(6, "10", 4)),
+				comments: map[int]int{1: 2, 2: 0, 3: 2, 4: 5, 5: 5, 6: 5},
+			},
+			{
+				users:    []models.User{bob, carol, dave},
+				posts:    []models.Post{post(3, "2", 2), post(4, "3", 3), post(6, "10", 4)},
+				comments: map[int]int{3: 9, 4: 5, 6: 0},
+			},
+			base,
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ix := NewIndex(time.Minute)
+			if ix.Snapshot() != nil {
+				t.Fatal("snapshot before the first pull")
+			}
+			for i, st := range tt.pulls {
+				now := t0.Add(time.Duration(i) * time.Minute)
+				ix.Apply(st.users, st.posts, st.comments, now)
+
+				got, want := ix.Snapshot(), recompute(st)
+				if !got.RefreshedAt.Equal(now) || got.RefreshInterval != time.Minute {
+					t.Errorf("pull %d: refreshed at %v every %v", i, got.RefreshedAt, got.RefreshInterval)
+				}
+				if !reflect.DeepEqual(got.TopUsers, want.TopUsers) {
+					t.Errorf("pull %d: TopUsers\n got %+v\nwant %+v", i, got.TopUsers, want.TopUsers)
+				}
+				if !reflect.DeepEqual(got.LatestPosts, want.LatestPosts) {
+					t.Errorf("pull %d: LatestPosts\n got %+v\nwant %+v", i, got.LatestPosts, want.LatestPosts)
+				}
+				if !reflect.DeepEqual(got.MostCommented, want.MostCommented) {
+					t.Errorf("pull %d: MostCommented\n got %+v\nwant %+v", i, got.MostCommented, want.MostCommented)
+				}
+			}
+		})
+	}
+}
+
+func TestSnapshotsAreImmutable(t *testing.T) {
+	ix := NewIndex(time.Minute)
+	ix.Apply(base.users, base.posts, base.comments, t0)
+	first := ix.Snapshot()
+	want := recompute(base)
+
+	ix.Apply([]models.User{bob}, []models.Post{post(3, "2", 2)}, map[int]int{3: 1}, t0.Add(time.Minute))
+	if !reflect.DeepEqual(first.TopUsers, want.TopUsers) || !reflect.DeepEqual(first.MostCommented, want.MostCommented) {
+		t.Error("a later pull changed an earlier snapshot")
+	}
+}
+
+func TestSnapshotStale(t *testing.T) {
+	snap := &Snapshot{RefreshedAt: t0, RefreshInterval: time.Minute}
+	if snap.Stale(t0.Add(2 * time.Minute)) {
+		t.Error("stale after two intervals")
+	}
+	if !snap.Stale(t0.Add(2*time.Minute + time.Second)) {
+		t.Error("not stale after missing two refreshes")
+	}
+}
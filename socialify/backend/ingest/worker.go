@@ -0,0 +1,114 @@
+package ingest
+
+import (
+	"context"
+	"log"
+	"os"
+	"socialify/backend/fetch"
+	"socialify/backend/models"
+	"time"
+)
+
+// DefaultInterval is used when INGEST_INTERVAL is unset.
+const DefaultInterval = 30 * time.Second
+
+// IntervalFromEnv reads the refresh interval from INGEST_INTERVAL. Zero
+// means background ingestion is disabled.
+func IntervalFromEnv() time.Duration {
+	v := os.Getenv("INGEST_INTERVAL")
+	if v == "" {
+		return DefaultInterval
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		log.Printf("ignoring invalid INGEST_INTERVAL %q", v)
+		return DefaultInterval
+	}
+	return d
+}
+
+// Pull is one complete read of the data source.
+type Pull struct {
+	Users    []models.User
+	Posts    []models.Post
+	Comments []models.Comment
+}
+
+// Worker periodically pulls every user, post and comment from the data
+// source into an Index. Interval must be positive.
+type Worker struct {
+	Index    *Index
+	Fetcher  *fetch.Orchestrator
+	Interval time.Duration
+	// OnRefresh is called with each successful pull, after the index has
+	// been updated. Add to it before calling Run.
+	OnRefresh []func(Pull)
+}
+
+func NewWorker(fetcher *fetch.Orchestrator, interval time.Duration) *Worker {
+	return &Worker{
+		Index:    NewIndex(interval),
+		Fetcher:  fetcher,
+		Interval: interval,
+	}
+}
+
+// Run refreshes the index immediately and then every Interval until ctx is
+// done. Failed refreshes are logged and leave the index as it was.
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.Refresh(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("ingest: refresh failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh performs one pull, bounded by the refresh interval.
+func (w *Worker) Refresh(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, w.Interval)
+	defer cancel()
+
+	users, err := w.Fetcher.Source.ListUsers(ctx)
+	if err != nil {
+		return err
+	}
+
+	postsByUser, err := w.Fetcher.PostsByUser(ctx, users)
+	if err != nil {
+		return err
+	}
+
+	posts := make([]models.Post, 0)
+	for _, userPosts := range postsByUser {
+		posts = append(posts, userPosts...)
+	}
+
+	commentsByPost, err := w.Fetcher.CommentsByPost(ctx, posts)
+	if err != nil {
+		return err
+	}
+
+	comments := make([]models.Comment, 0)
+	commentCounts := make(map[int]int, len(posts))
+	for i, postComments := range commentsByPost {
+		comments = append(comments, postComments...)
+		commentCounts[posts[i].ID] = len(postComments)
+	}
+
+	w.Index.Apply(users, posts, commentCounts, time.Now())
+
+	pull := Pull{Users: users, Posts: posts, Comments: comments}
+	for _, fn := range w.OnRefresh {
+		fn(pull)
+	}
+	return nil
+}
@@ -1,25 +1,48 @@
 package models
 
+import "time"
+
 type User struct {
 	ID   string `json:"id"`
 	Name string `json:"name"`
 }
 
 type Post struct {
-	ID      int    `json:"id"`
-	UserID  string `json:"userid"`
-	Content string `json:"content"`
+	ID        int       `json:"id"`
+	UserID    string    `json:"userid"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"createdAt"`
 }
 
+// Comment is a comment on a post. UserID is the commenter, empty if the
+// source does not say.
 type Comment struct {
-	ID      int    `json:"id"`
-	PostID  int    `json:"postid"`
-	Content string `json:"content"`
+	ID        int       `json:"id"`
+	PostID    int       `json:"postid"`
+	UserID    string    `json:"userid"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"createdAt"`
+	// Sentiment scores the content from -1 (negative) to 1 (positive).
+	Sentiment float64 `json:"sentiment"`
 }
 
 type UserPostCount struct {
-	User      User `json:"user"`
-	PostCount int  `json:"postCount"`
+	User       User        `json:"user"`
+	PostCount  int         `json:"postCount"`
+	Engagement *Engagement `json:"engagement,omitempty"`
+	Rank       int         `json:"rank,omitempty"`
+	// Movement and PreviousRank compare Rank with the last leaderboard
+	// snapshot.
+	Movement     string `json:"movement,omitempty"`
+	PreviousRank int    `json:"previousRank,omitempty"`
+}
+
+// Engagement is how much response a user's posts get. Score is the value of
+// the leaderboard metric the user was ranked by.
+type Engagement struct {
+	CommentsReceived int     `json:"commentsReceived"`
+	CommentsPerPost  float64 `json:"commentsPerPost"`
+	Score            float64 `json:"score"`
 }
 
 type PostCommentCount struct {
@@ -27,6 +50,46 @@ type PostCommentCount struct {
 	CommentCount int  `json:"commentCount"`
 }
 
+type PostWithUser struct {
+	Post         Post `json:"post"`
+	User         User `json:"user"`
+	CommentCount int  `json:"commentCount,omitempty"`
+}
+
+type RankedPost struct {
+	Post         Post   `json:"post"`
+	User         User   `json:"user"`
+	CommentCount int    `json:"commentCount"`
+	Rank         int    `json:"rank"`
+	Movement     string `json:"movement,omitempty"`
+	PreviousRank int    `json:"previousRank,omitempty"`
+}
+
+type TrendingPost struct {
+	Post         Post    `json:"post"`
+	User         User    `json:"user"`
+	CommentCount int     `json:"commentCount"`
+	Score        float64 `json:"score"`
+	Rank         int     `json:"rank"`
+}
+
+type TopicCount struct {
+	Topic     string  `json:"topic"`
+	PostCount int     `json:"postCount"`
+	UserCount int     `json:"userCount,omitempty"`
+	Share     float64 `json:"share,omitempty"`
+	Rank      int     `json:"rank,omitempty"`
+}
+
+// SearchHit is a post or comment matching a search, with its content
+// highlighted.
+type SearchHit struct {
+	Post    *Post    `json:"post,omitempty"`
+	Comment *Comment `json:"comment,omitempty"`
+	Score   float64  `json:"score"`
+	Snippet string   `json:"snippet"`
+}
+
 type RegisterRequest struct {
 	CompanyName string `json:"companyName"`
 	OwnerName   string `json:"ownerName"`
@@ -58,3 +121,65 @@ type AuthResponse struct {
 	AccessToken string `json:"access_token"`
 	ExpiresIn   int    `json:"expires_in"`
 }
+
+// Sentiment sums up how a set of comments is meant. Score is the mean of
+// their sentiment scores.
+type Sentiment struct {
+	Comments int     `json:"comments"`
+	Positive int     `json:"positive"`
+	Neutral  int     `json:"neutral"`
+	Negative int     `json:"negative"`
+	Score    float64 `json:"score"`
+}
+
+type PostSentiment struct {
+	Post      Post      `json:"post"`
+	User      User      `json:"user"`
+	Sentiment Sentiment `json:"sentiment"`
+	Rank      int       `json:"rank"`
+}
+
+// ActivityBucket counts what was created from Start until the next bucket.
+type ActivityBucket struct {
+	Start time.Time `json:"start"`
+	Label string    `json:"label"`
+	Count int       `json:"count"`
+}
+
+// RankPoint is a place on a leaderboard snapshot. Rank is 0 if the entry
+// was not on the leaderboard.
+type RankPoint struct {
+	TakenAt time.Time `json:"takenAt"`
+	Rank    int       `json:"rank"`
+	Score   float64   `json:"score"`
+}
+
+// Interactor is a user another user has exchanged comments with. Given is
+// the number of comments the other user wrote on the interactor's posts and
+// Received the number the interactor wrote on theirs. Mutual is set when
+// both are positive.
+type Interactor struct {
+	User     User `json:"user"`
+	Given    int  `json:"given"`
+	Received int  `json:"received"`
+	Total    int  `json:"total"`
+	Mutual   bool `json:"mutual"`
+	Rank     int  `json:"rank"`
+}
+
+// MutualPair is two users who have commented on each other's posts. Given[i]
+// is the number of comments Users[i] wrote on the other's posts, and
+// Strength the smaller of the two.
+type MutualPair struct {
+	Users    [2]User `json:"users"`
+	Given    [2]int  `json:"given"`
+	Strength int     `json:"strength"`
+	Rank     int     `json:"rank"`
+}
+
+// InfluentialUser is a user with their PageRank in the interaction graph.
+type InfluentialUser struct {
+	User  User    `json:"user"`
+	Score float64 `json:"score"`
+	Rank  int     `json:"rank"`
+}
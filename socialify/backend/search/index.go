@@ -0,0 +1,244 @@
+package search
+
+import (
+	"math"
+	"socialify/backend/models"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Document kinds.
+const (
+	KindPost    = "posts"
+	KindComment = "comments"
+)
+
+// BM25 parameters, and the weight of a term matched only by prefix
+// relative to an exact match.
+const (
+	k1           = 1.2
+	b            = 0.75
+	prefixWeight = 0.5
+)
+
+// Hit is one search result. Exactly one of Post and Comment is set.
+type Hit struct {
+	Post    *models.Post
+	Comment *models.Comment
+	Score   float64
+	Snippet string
+}
+
+type docKey struct {
+	kind string
+	id   int
+}
+
+type doc struct {
+	post    *models.Post
+	comment *models.Comment
+	content string
+	length  int
+}
+
+// Index is an inverted index over post and comment content. Sync keeps it
+// in step with the data source; only changed documents are reindexed.
+type Index struct {
+	mu       sync.RWMutex
+	docs     map[docKey]*doc
+	postings map[string]map[docKey]int
+	terms    []string
+	totalLen map[string]int
+	count    map[string]int
+	syncedAt time.Time
+}
+
+func NewIndex() *Index {
+	return &Index{
+		docs:     make(map[docKey]*doc),
+		postings: make(map[string]map[docKey]int),
+		totalLen: make(map[string]int),
+		count:    make(map[string]int),
+	}
+}
+
+// Build returns an index over posts and comments.
+func Build(posts []models.Post, comments []models.Comment) *Index {
+	ix := NewIndex()
+	ix.Sync(posts, comments, time.Now())
+	return ix
+}
+
+// SyncedAt returns when Sync was last called, or the zero time if never.
+func (ix *Index) SyncedAt() time.Time {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return ix.syncedAt
+}
+
+// Sync makes the index hold exactly posts and comments.
+func (ix *Index) Sync(posts []models.Post, comments []models.Comment, now time.Time) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	seen := make(map[docKey]bool, len(posts)+len(comments))
+	for i := range posts {
+		post := posts[i]
+		key := docKey{KindPost, post.ID}
+		seen[key] = true
+		if d, ok := ix.docs[key]; ok && *d.post == post {
+			continue
+		}
+		ix.putLocked(key, &doc{post: &post, content: post.Content})
+	}
+	for i := range comments {
+		comment := comments[i]
+		key := docKey{KindComment, comment.ID}
+		seen[key] = true
+		if d, ok := ix.docs[key]; ok && *d.comment == comment {
+			continue
+		}
+		ix.putLocked(key, &doc{comment: &comment, content: comment.Content})
+	}
+	for key := range ix.docs {
+		if !seen[key] {
+			ix.removeLocked(key)
+		}
+	}
+
+	ix.terms = ix.terms[:0]
+	for term := range ix.postings {
+		ix.terms = append(ix.terms, term)
+	}
+	sort.Strings(ix.terms)
+	ix.syncedAt = now
+}
+
+func (ix *Index) putLocked(key docKey, d *doc) {
+	ix.removeLocked(key)
+
+	tokens := Tokenize(d.content)
+	d.length = len(tokens)
+	for _, t := range tokens {
+		if ix.postings[t.Term] == nil {
+			ix.postings[t.Term] = make(map[docKey]int)
+		}
+		ix.postings[t.Term][key]++
+	}
+	ix.docs[key] = d
+	ix.totalLen[key.kind] += d.length
+	ix.count[key.kind]++
+}
+
+func (ix *Index) removeLocked(key docKey) {
+	d, ok := ix.docs[key]
+	if !ok {
+		return
+	}
+	for _, t := range Tokenize(d.content) {
+		delete(ix.postings[t.Term], key)
+		if len(ix.postings[t.Term]) == 0 {
+			delete(ix.postings, t.Term)
+		}
+	}
+	delete(ix.docs, key)
+	ix.totalLen[key.kind] -= d.length
+	ix.count[key.kind]--
+}
+
+// Search returns the documents of kind that match every word of query,
+// most relevant first and ties by ID. A query word matches the words it
+// is a prefix of, with less weight than an exact match. Relevance is
+// scored with BM25.
+func (ix *Index) Search(query, kind string) []Hit {
+	words := Terms(query)
+	if len(words) == 0 {
+		return []Hit{}
+	}
+
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	n := float64(ix.count[kind])
+	if n == 0 {
+		return []Hit{}
+	}
+	avgLen := float64(ix.totalLen[kind]) / n
+
+	var scores map[docKey]float64
+	for _, word := range words {
+		wordScores := make(map[docKey]float64)
+		for _, term := range ix.expandLocked(word) {
+			weight := 1.0
+			if term != word {
+				weight = prefixWeight
+			}
+
+			df := 0
+			for key := range ix.postings[term] {
+				if key.kind == kind {
+					df++
+				}
+			}
+			idf := math.Log(1 + (n-float64(df)+0.5)/(float64(df)+0.5))
+
+			for key, tf := range ix.postings[term] {
+				if key.kind != kind {
+					continue
+				}
+				norm := 1 - b + b*float64(ix.docs[key].length)/avgLen
+				wordScores[key] += weight * idf * float64(tf) * (k1 + 1) / (float64(tf) + k1*norm)
+			}
+		}
+
+		if scores == nil {
+			scores = wordScores
+			continue
+		}
+		for key := range scores {
+			if s, ok := wordScores[key]; ok {
+				scores[key] += s
+			} else {
+				delete(scores, key)
+			}
+		}
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for key, score := range scores {
+		d := ix.docs[key]
+		hits = append(hits, Hit{
+			Post:    d.post,
+			Comment: d.comment,
+			Score:   score,
+			Snippet: Highlight(d.content, words),
+		})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hitID(hits[i]) < hitID(hits[j])
+	})
+	return hits
+}
+
+// expandLocked returns the indexed terms word is a prefix of, including
+// word itself.
+func (ix *Index) expandLocked(word string) []string {
+	i := sort.SearchStrings(ix.terms, word)
+	var terms []string
+	for ; i < len(ix.terms) && strings.HasPrefix(ix.terms[i], word); i++ {
+		terms = append(terms, ix.terms[i])
+	}
+	return terms
+}
+
+func hitID(h Hit) int {
+	if h.Post != nil {
+		return h.Post.ID
+	}
+	return h.Comment.ID
+}
@@ -0,0 +1,121 @@
+package search
+
+import (
+	"fmt"
+	"reflect"
+	"socialify/backend/models"
+	"strings"
+	"testing"
+)
+
+func testIndex() *Index {
+	posts := []models.Post{
+		{ID: 1, Content: "Ocean waves at the ocean"},
+		{ID: 2, Content: "Ocean view from the mountain"},
+		{ID: 3, Content: "A quiet forest path far from any ocean or sea, winding between old trees"},
+		{ID: 4, Content: "Mountain hiking"},
+		{ID: 5, Content: "hike"},
+		{ID: 6, Content: "hiker"},
+		{ID: 8, Content: "twin"},
+		{ID: 7, Content: "twin"},
+	}
+	comments := []models.Comment{
+		{ID: 10, PostID: 1, Content: "Love the ocean"},
+	}
+	return Build(posts, comments)
+}
+
+func hitIDs(hits []Hit) []int {
+	ids := make([]int, 0, len(hits))
+	for _, h := range hits {
+		ids = append(ids, hitID(h))
+	}
+	return ids
+}
+
+func TestIndexSearch(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		kind  string
+		want  []int
+	}{
+		{"more occurrences rank higher, then shorter documents", "ocean", KindPost, []int{1, 2, 3}},
+		{"case and punctuation are ignored", "OCEAN!", KindPost, []int{1, 2, 3}},
+		{"every word must match", "ocean mountain", KindPost, []int{2}},
+		{"prefix of the last word", "ocean moun", KindPost, []int{2}},
+		{"prefix ranks shorter documents first", "mount", KindPost, []int{4, 2}},
+		{"exact match beats a prefix match", "hike", KindPost, []int{5, 6}},
+		{"ties by ID", "twin", KindPost, []int{7, 8}},
+		{"comments are searched separately", "ocean", KindComment, []int{10}},
+		{"no match", "desert", KindPost, []int{}},
+		{"empty query", "", KindPost, []int{}},
+		{"punctuation only", "?!, ...", KindPost, []int{}},
+	}
+	ix := testIndex()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hits := ix.Search(tt.query, tt.kind)
+			if got := hitIDs(hits); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Search(%q, %s) = %v, want %v", tt.query, tt.kind, got, tt.want)
+			}
+			for i := 1; i < len(hits); i++ {
+				if hits[i].Score > hits[i-1].Score {
+					t.Errorf("hit %d scores %v above hit %d's %v", i, hits[i].Score, i-1, hits[i-1].Score)
+				}
+			}
+		})
+	}
+}
+
+func TestIndexSyncReplacesDocuments(t *testing.T) {
+	ix := testIndex()
+	ix.Sync([]models.Post{{ID: 2, Content: "Desert view"}}, nil, ix.SyncedAt())
+
+	if got := hitIDs(ix.Search("ocean", KindPost)); len(got) != 0 {
+		t.Errorf("ocean still matches %v", got)
+	}
+	if got := hitIDs(ix.Search("desert", KindPost)); !reflect.DeepEqual(got, []int{2}) {
+		t.Errorf("desert matches %v, want [2]", got)
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	// words are w0 … w29 with "target" in place of w15.
+	words := make([]string, 30)
+	for i := range words {
+		words[i] = fmt.Sprintf("w%d", i)
+	}
+	words[15] = "target"
+	long := strings.Join(words, " ")
+
+	tests := []struct {
+		name  string
+		text  string
+		words []string
+		want  string
+	}{
+		{"exact match", "Ocean waves", []string{"ocean"}, "<mark>Ocean</mark> waves"},
+		{"prefix match", "Ocean waves", []string{"wav"}, "Ocean <mark>waves</mark>"},
+		{"every match is marked", "ocean, ocean!", []string{"ocean"}, "<mark>ocean</mark>, <mark>ocean</mark>!"},
+		{"text is escaped", `<b>ocean</b> & "sea"`, []string{"ocean"}, "&lt;b&gt;<mark>ocean</mark>&lt;/b&gt; &amp; &#34;sea&#34;"},
+		{"no match keeps the text", "calm sea", []string{"ocean"}, "calm sea"},
+		{
+			"long text is cut around the first match",
+			long, []string{"target"},
+			"…" + strings.Join(words[7:15], " ") + " <mark>target</mark> " + strings.Join(words[16:24], " ") + "…",
+		},
+		{
+			"match near the start",
+			long, []string{"w2"},
+			"w0 w1 <mark>w2</mark> " + strings.Join(words[3:11], " ") + "…",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Highlight(tt.text, tt.words); got != tt.want {
+				t.Errorf("got  %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
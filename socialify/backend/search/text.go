@@ -0,0 +1,104 @@
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+// snippetWords bounds how many words of context a snippet keeps before and
+// after the first match.
+const snippetWords = 8
+
+// Token is a word of a text and where it is.
+type Token struct {
+	Term       string
+	Start, End int
+}
+
+// Tokenize splits text into lower-cased words of letters and digits.
+func Tokenize(text string) []Token {
+	tokens := make([]Token, 0)
+	start := -1
+	for i, r := range text {
+		word := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if word && start < 0 {
+			start = i
+		}
+		if !word && start >= 0 {
+			tokens = append(tokens, Token{strings.ToLower(text[start:i]), start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, Token{strings.ToLower(text[start:]), start, len(text)})
+	}
+	return tokens
+}
+
+// Terms returns the distinct words of a query.
+func Terms(query string) []string {
+	seen := make(map[string]bool)
+	terms := make([]string, 0)
+	for _, t := range Tokenize(query) {
+		if !seen[t.Term] {
+			seen[t.Term] = true
+			terms = append(terms, t.Term)
+		}
+	}
+	return terms
+}
+
+// Highlight returns text around its first word matching one of words, with
+// every matching word wrapped in <mark> tags. A word matches if one of words
+// is a prefix of it. Text outside the tags is HTML-escaped.
+func Highlight(text string, words []string) string {
+	tokens := Tokenize(text)
+	matches := func(t Token) bool {
+		for _, w := range words {
+			if strings.HasPrefix(t.Term, w) {
+				return true
+			}
+		}
+		return false
+	}
+
+	first := -1
+	for i, t := range tokens {
+		if matches(t) {
+			first = i
+			break
+		}
+	}
+
+	from, to := 0, len(text)
+	if first >= 0 {
+		if i := first - snippetWords; i > 0 {
+			from = tokens[i].Start
+		}
+		if i := first + snippetWords; i < len(tokens)-1 {
+			to = tokens[i].End
+		}
+	}
+
+	var sb strings.Builder
+	if from > 0 {
+		sb.WriteString("…")
+	}
+	pos := from
+	for _, t := range tokens {
+		if t.Start < from || t.End > to || !matches(t) {
+			continue
+		}
+		sb.WriteString(html.EscapeString(text[pos:t.Start]))
+		sb.WriteString("<mark>")
+		sb.WriteString(html.EscapeString(text[t.Start:t.End]))
+		sb.WriteString("</mark>")
+		pos = t.End
+	}
+	sb.WriteString(html.EscapeString(text[pos:to]))
+	if to < len(text) {
+		sb.WriteString("…")
+	}
+	return sb.String()
+}
@@ -0,0 +1,117 @@
+package sentiment
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Labels for scores.
+const (
+	Positive = "positive"
+	Neutral  = "neutral"
+	Negative = "negative"
+)
+
+// threshold is how far from zero a score has to be to count as positive or
+// negative.
+const threshold = 0.05
+
+// alpha sets how quickly Score approaches ±1 as word valences add up.
+const alpha = 15
+
+// negationWindow is how many words after a negator have their valence
+// flipped.
+const negationWindow = 3
+
+// negationFactor scales a negated valence. Negating a word weakens it as
+// well as flipping it: "not great" is milder than "bad".
+const negationFactor = -0.75
+
+// lexicon maps words to their valence, from -4 (very negative) to 4 (very
+// positive).
+var lexicon = map[string]float64{
+	"abysmal": -4, "agree": 1, "amazing": 4, "angry": -3, "annoying": -2,
+	"awesome": 4, "awful": -3, "bad": -3, "beautiful": 3, "best": 3,
+	"boring": -2, "brilliant": 4, "broken": -2, "clever": 2,
+	"confusing": -2, "cool": 2, "cute": 2, "disagree": -2,
+	"disappointing": -2, "dislike": -2, "dull": -2, "enjoy": 2,
+	"enjoyed": 2, "excellent": 3, "fake": -3, "fantastic": 4, "fun": 2,
+	"funny": 2, "good": 2, "great": 3, "hate": -3, "hilarious": 3,
+	"horrible": -3, "insightful": 3, "interesting": 2, "liked": 2,
+	"lmao": 3, "lol": 3, "love": 3, "loved": 3, "lovely": 3, "meh": -1,
+	"nice": 3, "pointless": -2, "poor": -2, "rofl": 4, "sad": -2,
+	"silly": -1, "spam": -2, "stupid": -3, "superb": 4, "terrible": -3,
+	"thanks": 2, "ugly": -3, "useful": 2, "useless": -2, "weak": -2,
+	"wonderful": 4, "worst": -3, "wow": 3, "wrong": -2, "wtf": -4,
+}
+
+// negators flip the valence of the words that follow them.
+var negators = map[string]bool{
+	"not": true, "no": true, "never": true, "none": true, "nothing": true,
+	"nobody": true, "neither": true, "nor": true, "without": true,
+	"cant": true, "cannot": true, "dont": true, "doesnt": true,
+	"didnt": true, "isnt": true, "wasnt": true, "arent": true,
+	"werent": true, "wont": true, "wouldnt": true, "shouldnt": true,
+}
+
+// boosters scale the valence of the word after them.
+var boosters = map[string]float64{
+	"very": 1.5, "really": 1.5, "so": 1.5, "extremely": 2, "super": 1.5,
+	"totally": 1.5, "absolutely": 2, "incredibly": 2, "quite": 1.25,
+	"slightly": 0.5, "somewhat": 0.5, "kinda": 0.5, "barely": 0.5,
+}
+
+// words splits text into lower-case words of letters and digits, dropping
+// apostrophes so that "don't" reads as "dont".
+func words(text string) []string {
+	text = strings.NewReplacer("'", "", "’", "").Replace(strings.ToLower(text))
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Score rates the sentiment of text from -1 (most negative) to 1 (most
+// positive) by adding up the valences of its words. Text without any
+// words from the lexicon scores 0.
+func Score(text string) float64 {
+	sum := 0.0
+	boost := 1.0
+	negated := 0
+	for _, word := range words(text) {
+		if negators[word] {
+			negated = negationWindow
+			boost = 1
+			continue
+		}
+		if b, ok := boosters[word]; ok {
+			boost *= b
+			continue
+		}
+
+		if valence, ok := lexicon[word]; ok {
+			valence *= boost
+			if negated > 0 {
+				valence *= negationFactor
+			}
+			sum += valence
+		}
+		boost = 1
+		if negated > 0 {
+			negated--
+		}
+	}
+	return sum / math.Sqrt(sum*sum+alpha)
+}
+
+// Label names the sentiment of a score.
+func Label(score float64) string {
+	switch {
+	case score >= threshold:
+		return Positive
+	case score <= -threshold:
+		return Negative
+	default:
+		return Neutral
+	}
+}
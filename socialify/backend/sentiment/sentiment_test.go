@@ -0,0 +1,63 @@
+package sentiment
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// norm is the normalisation Score applies to the sum of valences.
+func norm(sum float64) float64 {
+	return sum / math.Sqrt(sum*sum+alpha)
+}
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		text string
+		want float64
+	}{
+		{"", 0},
+		{"the cat sat on the mat", 0},
+		{"Good!", norm(2)},
+		{"good but boring", norm(2 - 2)},
+		{"not good", norm(2 * negationFactor)},
+		{"I don't love it", norm(3 * negationFactor)},
+		{"not bad", norm(-3 * negationFactor)},
+		{"not a big deal, good", norm(2)},
+		{"not so very good", norm(2 * 1.5 * 1.5 * negationFactor)},
+		{"very good", norm(2 * 1.5)},
+		{"very very good", norm(2 * 1.5 * 1.5)},
+		{"slightly good", norm(2 * 0.5)},
+		{"very, the good", norm(2)},
+		{"very not good", norm(2 * negationFactor)},
+	}
+	for _, tt := range tests {
+		if got := Score(tt.text); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Score(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestScoreBounds(t *testing.T) {
+	tests := []struct {
+		text  string
+		label string
+	}{
+		{strings.Repeat("amazing ", 100), Positive},
+		{strings.Repeat("extremely wtf ", 100), Negative},
+		{"meh okay", Negative},
+		{"ok", Neutral},
+	}
+	for _, tt := range tests {
+		got := Score(tt.text)
+		if got < -1 || got > 1 {
+			t.Errorf("Score(%.20q…) = %v, outside [-1, 1]", tt.text, got)
+		}
+		if label := Label(got); label != tt.label {
+			t.Errorf("Label(Score(%.20q…)) = %s, want %s", tt.text, label, tt.label)
+		}
+	}
+	if got := Score(strings.Repeat("amazing ", 100)); got < 0.99 {
+		t.Errorf("many positive words score %v, want close to 1", got)
+	}
+}
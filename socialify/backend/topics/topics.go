@@ -0,0 +1,182 @@
+package topics
+
+import (
+	"socialify/backend/models"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// stopwords are left out of topics: common English words, plus the
+// boilerplate of post content such as "Post about".
+var stopwords = map[string]bool{}
+
+func init() {
+	for _, w := range strings.Fields(`
+		a about above after again all also am an and any are as at be because
+		been before being below between both but by can could did do does
+		doing down during each few for from further had has have having he
+		her here hers him his how i if in into is it its just me more most
+		my no nor not now of off on once only or other our ours out over own
+		same she should so some such than that the their theirs them then
+		there these they this those through to too under until up very was
+		we were what when where which while who whom why will with would you
+		your yours
+		post posts comment comments`) {
+		stopwords[w] = true
+	}
+}
+
+// Tokenize splits text into lower-case words of letters and digits.
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Extract returns the distinct topics of text in order of first appearance:
+// its words other than stopwords and single characters.
+func Extract(text string) []string {
+	seen := make(map[string]bool)
+	topics := make([]string, 0)
+	for _, word := range Tokenize(text) {
+		if len([]rune(word)) < 2 || stopwords[word] || seen[word] {
+			continue
+		}
+		seen[word] = true
+		topics = append(topics, word)
+	}
+	return topics
+}
+
+// Count is how often a topic occurs.
+type Count struct {
+	Topic     string
+	PostCount int
+	UserCount int
+}
+
+// Index counts the posts and users per topic. Sync keeps it in step with
+// the data source; only changed posts are recounted. It is safe for
+// concurrent use.
+type Index struct {
+	mu sync.RWMutex
+	// docs holds the topics of every post, by post ID.
+	docs  map[int]doc
+	posts map[string]map[int]bool
+	// users counts each user's posts per topic.
+	users    map[string]map[string]int
+	syncedAt time.Time
+}
+
+type doc struct {
+	post   models.Post
+	topics []string
+}
+
+func NewIndex() *Index {
+	return &Index{
+		docs:  make(map[int]doc),
+		posts: make(map[string]map[int]bool),
+		users: make(map[string]map[string]int),
+	}
+}
+
+// Build returns an index over posts.
+func Build(posts []models.Post) *Index {
+	ix := NewIndex()
+	ix.Sync(posts, time.Now())
+	return ix
+}
+
+// SyncedAt returns when Sync was last called, or the zero time if never.
+func (ix *Index) SyncedAt() time.Time {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return ix.syncedAt
+}
+
+// Sync makes the index hold exactly posts.
+func (ix *Index) Sync(posts []models.Post, now time.Time) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	seen := make(map[int]bool, len(posts))
+	for _, post := range posts {
+		seen[post.ID] = true
+		if d, ok := ix.docs[post.ID]; ok && d.post == post {
+			continue
+		}
+		ix.removeLocked(post.ID)
+		ix.addLocked(post)
+	}
+	for id := range ix.docs {
+		if !seen[id] {
+			ix.removeLocked(id)
+		}
+	}
+	ix.syncedAt = now
+}
+
+func (ix *Index) addLocked(post models.Post) {
+	d := doc{post: post, topics: Extract(post.Content)}
+	for _, topic := range d.topics {
+		if ix.posts[topic] == nil {
+			ix.posts[topic] = make(map[int]bool)
+			ix.users[topic] = make(map[string]int)
+		}
+		ix.posts[topic][post.ID] = true
+		ix.users[topic][post.UserID]++
+	}
+	ix.docs[post.ID] = d
+}
+
+func (ix *Index) removeLocked(id int) {
+	d, ok := ix.docs[id]
+	if !ok {
+		return
+	}
+	for _, topic := range d.topics {
+		delete(ix.posts[topic], id)
+		if ix.users[topic][d.post.UserID]--; ix.users[topic][d.post.UserID] == 0 {
+			delete(ix.users[topic], d.post.UserID)
+		}
+		if len(ix.posts[topic]) == 0 {
+			delete(ix.posts, topic)
+			delete(ix.users, topic)
+		}
+	}
+	delete(ix.docs, id)
+}
+
+// Counts returns every topic, most posts first and ties by topic.
+func (ix *Index) Counts() []Count {
+	ix.mu.RLock()
+	counts := make([]Count, 0, len(ix.posts))
+	for topic, posts := range ix.posts {
+		counts = append(counts, Count{Topic: topic, PostCount: len(posts), UserCount: len(ix.users[topic])})
+	}
+	ix.mu.RUnlock()
+
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].PostCount != counts[j].PostCount {
+			return counts[i].PostCount > counts[j].PostCount
+		}
+		return counts[i].Topic < counts[j].Topic
+	})
+	return counts
+}
+
+// Mentions reports whether the post with postID is about topic.
+func (ix *Index) Mentions(postID int, topic string) bool {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return ix.posts[topic][postID]
+}
+
+// Normalize returns topic in the form Extract produces.
+func Normalize(topic string) string {
+	return strings.ToLower(strings.TrimSpace(topic))
+}
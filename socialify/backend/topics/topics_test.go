@@ -0,0 +1,58 @@
+package topics
+
+import (
+	"reflect"
+	"socialify/backend/models"
+	"testing"
+	"time"
+)
+
+func TestExtract(t *testing.T) {
+	got := Extract("Post about the Ocean, ocean waves and a 4k view!")
+	want := []string{"ocean", "waves", "4k", "view"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestIndexSync(t *testing.T) {
+	p := func(id int, userID, content string) models.Post {
+		return models.Post{ID: id, UserID: userID, Content: content}
+	}
+	pulls := [][]models.Post{
+		{p(1, "1", "ocean waves"), p(2, "1", "ocean view"), p(3, "2", "mountain view")},
+		// Post edited, one removed, one added.
+		{p(1, "1", "ocean waves"), p(2, "1", "forest view"), p(4, "3", "ocean")},
+		// A user's last post about a topic goes.
+		{p(1, "1", "waves"), p(4, "3", "ocean")},
+		{},
+		{p(5, "2", "ocean ocean ocean")},
+	}
+
+	ix := NewIndex()
+	for i, posts := range pulls {
+		now := time.Date(2025, 3, 1, 0, i, 0, 0, time.UTC)
+		ix.Sync(posts, now)
+
+		want := Build(posts)
+		if got, want := ix.Counts(), want.Counts(); !reflect.DeepEqual(got, want) {
+			t.Errorf("pull %d: counts\n got %+v\nwant %+v", i, got, want)
+		}
+		if !ix.SyncedAt().Equal(now) {
+			t.Errorf("pull %d: synced at %v", i, ix.SyncedAt())
+		}
+		for _, post := range posts {
+			for _, topic := range Extract(post.Content) {
+				if !ix.Mentions(post.ID, topic) {
+					t.Errorf("pull %d: post %d not about %q", i, post.ID, topic)
+				}
+			}
+		}
+	}
+	if ix.Mentions(2, "ocean") || ix.Mentions(3, "view") {
+		t.Error("removed posts are still indexed")
+	}
+	if len(ix.users) != 1 || ix.users["ocean"]["2"] != 1 {
+		t.Errorf("users per topic = %v, want only user 2 for ocean", ix.users)
+	}
+}
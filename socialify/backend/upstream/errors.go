@@ -0,0 +1,86 @@
+package upstream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Kinds of upstream failure. Errors returned by the fetch layer match one of
+// these with errors.Is.
+var (
+	ErrNotFound            = errors.New("not found")
+	ErrUnauthorized        = errors.New("unauthorized")
+	ErrRateLimited         = errors.New("rate limited")
+	ErrUpstreamUnavailable = errors.New("upstream unavailable")
+	ErrDecode              = errors.New("malformed upstream response")
+)
+
+// Error is a failed upstream operation. Kind is one of the Err* values above
+// and Err, if set, is the underlying cause. Neither carries the upstream
+// response body.
+type Error struct {
+	Kind   error
+	Op     string
+	Status int
+	Err    error
+}
+
+func (e *Error) Error() string {
+	msg := e.Op + ": " + e.Kind.Error()
+	if e.Status != 0 {
+		msg += fmt.Sprintf(" (status %d)", e.Status)
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// FromStatus classifies a non-2xx upstream response status.
+func FromStatus(op string, status int) error {
+	var kind error
+	switch {
+	case status == http.StatusNotFound:
+		kind = ErrNotFound
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		kind = ErrUnauthorized
+	case status == http.StatusTooManyRequests:
+		kind = ErrRateLimited
+	default:
+		kind = ErrUpstreamUnavailable
+	}
+	return &Error{Kind: kind, Op: op, Status: status}
+}
+
+// FromTransport classifies an error from sending a request. Context errors
+// and errors that are already classified are returned unchanged.
+func FromTransport(op string, err error) error {
+	var classified *Error
+	if errors.As(err, &classified) {
+		return err
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return err
+	}
+	return &Error{Kind: ErrUpstreamUnavailable, Op: op, Err: err}
+}
+
+// NotFound reports that the resource named by op does not exist.
+func NotFound(op string) error {
+	return &Error{Kind: ErrNotFound, Op: op}
+}
+
+// Decode reports an upstream response that could not be parsed.
+func Decode(op string, err error) error {
+	return &Error{Kind: ErrDecode, Op: op, Err: err}
+}
@@ -0,0 +1,54 @@
+package upstream
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// Problem is the client-facing form of an error: an HTTP status, a stable
+// machine-readable code and a message that is safe to show.
+type Problem struct {
+	Status  int    `json:"-"`
+	Code    string `json:"code"`
+	Message string `json:"error"`
+}
+
+// StatusClientClosedRequest is the nginx status for a request whose client
+// went away before the response was ready.
+const StatusClientClosedRequest = 499
+
+// Describe translates err into the Problem sent to API clients. Errors that
+// are not recognised become a generic internal error so their text never
+// reaches the client.
+func Describe(err error) Problem {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return Problem{http.StatusNotFound, "not_found", "resource not found"}
+	case errors.Is(err, ErrUnauthorized):
+		return Problem{http.StatusUnauthorized, "upstream_unauthorized", "not authorized with the upstream service"}
+	case errors.Is(err, ErrRateLimited):
+		return Problem{http.StatusTooManyRequests, "upstream_rate_limited", "upstream rate limit exceeded, try again later"}
+	case errors.Is(err, ErrUpstreamUnavailable):
+		return Problem{http.StatusBadGateway, "upstream_unavailable", "upstream service unavailable"}
+	case errors.Is(err, ErrDecode):
+		return Problem{http.StatusBadGateway, "upstream_bad_response", "upstream service returned an invalid response"}
+	case errors.Is(err, context.Canceled):
+		return Problem{StatusClientClosedRequest, "client_closed_request", "request cancelled by the client"}
+	case errors.Is(err, context.DeadlineExceeded):
+		return Problem{http.StatusGatewayTimeout, "upstream_timeout", "upstream service did not respond in time"}
+	default:
+		return Problem{http.StatusInternalServerError, "internal_error", "internal server error"}
+	}
+}
+
+// Outage reports whether err means the upstream could not serve the data
+// right now, as opposed to the data not existing, the client hanging up or a
+// bug on our side.
+func Outage(err error) bool {
+	switch Describe(err).Status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
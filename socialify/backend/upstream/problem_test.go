@@ -0,0 +1,38 @@
+package upstream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestDescribe(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+		outage bool
+	}{
+		{"client hung up", fmt.Errorf("users: %w", context.Canceled), StatusClientClosedRequest, "client_closed_request", false},
+		{"deadline", fmt.Errorf("users: %w", context.DeadlineExceeded), http.StatusGatewayTimeout, "upstream_timeout", true},
+		{"breaker open", &Error{Kind: ErrUpstreamUnavailable, Op: "GET /users", Err: ErrCircuitOpen}, http.StatusBadGateway, "upstream_unavailable", true},
+		{"429", FromStatus("GET /users", http.StatusTooManyRequests), http.StatusTooManyRequests, "upstream_rate_limited", true},
+		{"5xx", FromStatus("GET /users", http.StatusServiceUnavailable), http.StatusBadGateway, "upstream_unavailable", true},
+		{"404", NotFound("user 9"), http.StatusNotFound, "not_found", false},
+		{"unknown", errors.New("secret detail"), http.StatusInternalServerError, "internal_error", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Describe(tt.err)
+			if p.Status != tt.status || p.Code != tt.code {
+				t.Errorf("got %d %s, want %d %s", p.Status, p.Code, tt.status, tt.code)
+			}
+			if got := Outage(tt.err); got != tt.outage {
+				t.Errorf("outage = %v, want %v", got, tt.outage)
+			}
+		})
+	}
+}
@@ -0,0 +1,281 @@
+package upstream
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is the cause of the upstream-unavailable errors returned
+// while an endpoint's circuit breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// RetryPolicy bounds retries of idempotent requests. Delays grow
+// exponentially from BaseDelay up to MaxDelay and are fully jittered.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// BreakerPolicy opens an endpoint's circuit after FailureThreshold
+// consecutive failures. After OpenFor one trial request is let through; its
+// outcome closes or re-opens the circuit.
+type BreakerPolicy struct {
+	FailureThreshold int
+	OpenFor          time.Duration
+}
+
+var (
+	DefaultRetryPolicy   = RetryPolicy{MaxAttempts: 3, BaseDelay: 100 * time.Millisecond, MaxDelay: 2 * time.Second}
+	DefaultBreakerPolicy = BreakerPolicy{FailureThreshold: 5, OpenFor: 30 * time.Second}
+)
+
+// Default is the transport shared by every client of the test server, so
+// breaker state is per endpoint rather than per client.
+var Default = NewTransport(http.DefaultTransport, DefaultRetryPolicy, DefaultBreakerPolicy)
+
+// Transport wraps another RoundTripper with retries and a circuit breaker per
+// endpoint. An endpoint is a method plus host and path with numeric path
+// segments replaced by ":id".
+type Transport struct {
+	Next    http.RoundTripper
+	Retry   RetryPolicy
+	Breaker BreakerPolicy
+
+	// now and sleep are replaced in tests.
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+
+	mu       sync.Mutex
+	breakers map[string]*breaker
+}
+
+type breaker struct {
+	state     string
+	failures  int
+	openedAt  time.Time
+	trial     bool
+	requests  uint64
+	retries   uint64
+	errors    uint64
+	rejected  uint64
+	lastError string
+}
+
+const (
+	StateClosed   = "closed"
+	StateOpen     = "open"
+	StateHalfOpen = "half-open"
+)
+
+// EndpointState is the externally visible state of one endpoint's breaker.
+type EndpointState struct {
+	Endpoint            string    `json:"endpoint"`
+	State               string    `json:"state"`
+	ConsecutiveFailures int       `json:"consecutiveFailures"`
+	OpenedAt            time.Time `json:"openedAt"`
+	Requests            uint64    `json:"requests"`
+	Retries             uint64    `json:"retries"`
+	Failures            uint64    `json:"failures"`
+	Rejected            uint64    `json:"rejected"`
+	LastError           string    `json:"lastError,omitempty"`
+}
+
+func NewTransport(next http.RoundTripper, retry RetryPolicy, breakerPolicy BreakerPolicy) *Transport {
+	return &Transport{
+		Next:     next,
+		Retry:    retry,
+		Breaker:  breakerPolicy,
+		now:      time.Now,
+		sleep:    sleep,
+		breakers: make(map[string]*breaker),
+	}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	endpoint := endpointOf(req)
+	attempts := 1
+	if idempotent(req.Method) && req.Body == nil && t.Retry.MaxAttempts > 1 {
+		attempts = t.Retry.MaxAttempts
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err := t.admit(endpoint); err != nil {
+			return nil, err
+		}
+
+		resp, err := t.Next.RoundTrip(req)
+		failed := err != nil || resp.StatusCode >= 500
+		if err != nil && req.Context().Err() != nil {
+			// The caller gave up; that says nothing about the endpoint.
+			t.release(endpoint)
+			return nil, err
+		}
+		t.record(endpoint, failed, err, resp)
+
+		retryable := failed || resp.StatusCode == http.StatusTooManyRequests
+		if !retryable || attempt+1 >= attempts {
+			return resp, err
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		t.countRetry(endpoint)
+		if err := t.sleep(req.Context(), t.backoff(attempt)); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// admit rejects the request if endpoint's circuit is open, and lets exactly
+// one trial request through once the open period has passed.
+func (t *Transport) admit(endpoint string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	b := t.breakerLocked(endpoint)
+	switch b.state {
+	case StateOpen:
+		if t.now().Sub(b.openedAt) < t.Breaker.OpenFor {
+			b.rejected++
+			return &Error{Kind: ErrUpstreamUnavailable, Op: endpoint, Err: ErrCircuitOpen}
+		}
+		b.state = StateHalfOpen
+		b.trial = true
+	case StateHalfOpen:
+		if b.trial {
+			b.rejected++
+			return &Error{Kind: ErrUpstreamUnavailable, Op: endpoint, Err: ErrCircuitOpen}
+		}
+		b.trial = true
+	}
+	b.requests++
+	return nil
+}
+
+// release undoes admit for a request whose outcome is not recorded.
+func (t *Transport) release(endpoint string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.breakerLocked(endpoint).trial = false
+}
+
+func (t *Transport) record(endpoint string, failed bool, err error, resp *http.Response) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	b := t.breakerLocked(endpoint)
+	b.trial = false
+	if !failed {
+		b.state = StateClosed
+		b.failures = 0
+		return
+	}
+
+	b.errors++
+	b.failures++
+	if err != nil {
+		b.lastError = err.Error()
+	} else {
+		b.lastError = resp.Status
+	}
+	if b.state == StateHalfOpen || b.failures >= t.Breaker.FailureThreshold {
+		b.state = StateOpen
+		b.openedAt = t.now()
+	}
+}
+
+func (t *Transport) countRetry(endpoint string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.breakerLocked(endpoint).retries++
+}
+
+func (t *Transport) breakerLocked(endpoint string) *breaker {
+	b, ok := t.breakers[endpoint]
+	if !ok {
+		b = &breaker{state: StateClosed}
+		t.breakers[endpoint] = b
+	}
+	return b
+}
+
+// backoff returns a random delay in [0, min(MaxDelay, BaseDelay*2^attempt)).
+func (t *Transport) backoff(attempt int) time.Duration {
+	ceiling := t.Retry.MaxDelay
+	if attempt < 30 {
+		if d := t.Retry.BaseDelay << uint(attempt); d > 0 && d < ceiling {
+			ceiling = d
+		}
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(ceiling)))
+}
+
+// State reports every endpoint seen so far, sorted by endpoint.
+func (t *Transport) State() []EndpointState {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	states := make([]EndpointState, 0, len(t.breakers))
+	for endpoint, b := range t.breakers {
+		state := b.state
+		if state == StateOpen && t.now().Sub(b.openedAt) >= t.Breaker.OpenFor {
+			state = StateHalfOpen
+		}
+		states = append(states, EndpointState{
+			Endpoint:            endpoint,
+			State:               state,
+			ConsecutiveFailures: b.failures,
+			OpenedAt:            b.openedAt,
+			Requests:            b.requests,
+			Retries:             b.retries,
+			Failures:            b.errors,
+			Rejected:            b.rejected,
+			LastError:           b.lastError,
+		})
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Endpoint < states[j].Endpoint })
+	return states
+}
+
+func endpointOf(req *http.Request) string {
+	segments := strings.Split(req.URL.Path, "/")
+	for i, segment := range segments {
+		if segment != "" && strings.Trim(segment, "0123456789") == "" {
+			segments[i] = ":id"
+		}
+	}
+	return req.Method + " " + req.URL.Host + strings.Join(segments, "/")
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case "GET", "HEAD", "OPTIONS":
+		return true
+	}
+	return false
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,261 @@
+package upstream
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// script is a stand-in upstream that answers with the given statuses in
+// turn, repeating the last one.
+type script struct {
+	mu       sync.Mutex
+	statuses []int
+	calls    int
+}
+
+func (s *script) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := s.statuses[len(s.statuses)-1]
+	if s.calls < len(s.statuses) {
+		status = s.statuses[s.calls]
+	}
+	s.calls++
+	w.WriteHeader(status)
+}
+
+func (s *script) Calls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls
+}
+
+// fakeClock is the transport's clock in tests. Sleeping advances it instead
+// of waiting.
+type fakeClock struct {
+	mu    sync.Mutex
+	now   time.Time
+	slept []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func (c *fakeClock) Sleep(ctx context.Context, d time.Duration) error {
+	c.mu.Lock()
+	c.slept = append(c.slept, d)
+	c.mu.Unlock()
+	c.Advance(d)
+	return ctx.Err()
+}
+
+var testRetry = RetryPolicy{MaxAttempts: 3, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+func newTestTransport(t *testing.T, statuses ...int) (*Transport, *script, *fakeClock, *httptest.Server) {
+	upstream := &script{statuses: statuses}
+	srv := httptest.NewServer(upstream)
+	t.Cleanup(srv.Close)
+
+	clock := &fakeClock{now: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)}
+	tr := NewTransport(http.DefaultTransport, testRetry, BreakerPolicy{FailureThreshold: 3, OpenFor: 30 * time.Second})
+	tr.now = clock.Now
+	tr.sleep = clock.Sleep
+	return tr, upstream, clock, srv
+}
+
+func get(t *testing.T, tr *Transport, url string) (int, error) {
+	t.Helper()
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := tr.RoundTrip(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+func TestTransportRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		status   int
+		calls    int
+	}{
+		{"success", []int{200}, 200, 1},
+		{"5xx then success", []int{503, 500, 200}, 200, 3},
+		{"429 then success", []int{429, 200}, 200, 2},
+		{"5xx until attempts run out", []int{502}, 502, 3},
+		{"no retry on 404", []int{404, 200}, 404, 1},
+		{"no retry on 401", []int{401, 200}, 401, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr, upstream, clock, srv := newTestTransport(t, tt.statuses...)
+
+			status, err := get(t, tr, srv.URL+"/users/1/posts")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if status != tt.status {
+				t.Errorf("status = %d, want %d", status, tt.status)
+			}
+			if upstream.Calls() != tt.calls {
+				t.Errorf("upstream calls = %d, want %d", upstream.Calls(), tt.calls)
+			}
+			if len(clock.slept) != tt.calls-1 {
+				t.Errorf("slept %d times, want %d", len(clock.slept), tt.calls-1)
+			}
+			for i, d := range clock.slept {
+				if ceiling := testRetry.BaseDelay << uint(i); d < 0 || d >= ceiling {
+					t.Errorf("backoff %d = %v, want below %v", i, d, ceiling)
+				}
+			}
+
+			state := tr.State()[0]
+			if state.Endpoint != "GET "+srv.Listener.Addr().String()+"/users/:id/posts" {
+				t.Errorf("endpoint = %q", state.Endpoint)
+			}
+			if state.Retries != uint64(tt.calls-1) {
+				t.Errorf("retries = %d, want %d", state.Retries, tt.calls-1)
+			}
+		})
+	}
+}
+
+func TestTransportNoRetryForPost(t *testing.T) {
+	tr, upstream, _, srv := newTestTransport(t, 503, 200)
+
+	req, _ := http.NewRequest("POST", srv.URL+"/auth", nil)
+	resp, err := tr.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 503 || upstream.Calls() != 1 {
+		t.Errorf("got %d after %d calls, want 503 after 1", resp.StatusCode, upstream.Calls())
+	}
+}
+
+func TestBreakerOpensAndFailsFast(t *testing.T) {
+	tr, upstream, _, srv := newTestTransport(t, 500)
+	tr.Retry.MaxAttempts = 1
+
+	for i := 0; i < tr.Breaker.FailureThreshold; i++ {
+		if status, err := get(t, tr, srv.URL+"/users"); err != nil || status != 500 {
+			t.Fatalf("request %d: %d, %v", i, status, err)
+		}
+	}
+	if state := tr.State()[0]; state.State != StateOpen || state.ConsecutiveFailures != 3 {
+		t.Fatalf("state = %s after %d failures, want open", state.State, state.ConsecutiveFailures)
+	}
+
+	_, err := get(t, tr, srv.URL+"/users")
+	if !errors.Is(err, ErrUpstreamUnavailable) || !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("got %v, want an open circuit error", err)
+	}
+	if upstream.Calls() != tr.Breaker.FailureThreshold {
+		t.Errorf("upstream calls = %d, want %d", upstream.Calls(), tr.Breaker.FailureThreshold)
+	}
+	if state := tr.State()[0]; state.Rejected != 1 {
+		t.Errorf("rejected = %d, want 1", state.Rejected)
+	}
+
+	// Other endpoints have their own breakers.
+	if status, err := get(t, tr, srv.URL+"/posts/1/comments"); err != nil || status != 500 {
+		t.Errorf("other endpoint: %d, %v", status, err)
+	}
+}
+
+func TestBreakerHalfOpenProbe(t *testing.T) {
+	tests := []struct {
+		name   string
+		probe  int
+		state  string
+		status int
+	}{
+		{"success closes", 200, StateClosed, 200},
+		{"failure reopens", 500, StateOpen, 500},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr, upstream, clock, srv := newTestTransport(t, 500, 500, 500, tt.probe)
+			tr.Retry.MaxAttempts = 1
+			for i := 0; i < 3; i++ {
+				get(t, tr, srv.URL+"/users")
+			}
+
+			clock.Advance(tr.Breaker.OpenFor - time.Second)
+			if _, err := get(t, tr, srv.URL+"/users"); !errors.Is(err, ErrCircuitOpen) {
+				t.Fatalf("before OpenFor: got %v, want an open circuit error", err)
+			}
+
+			clock.Advance(time.Second)
+			if state := tr.State()[0]; state.State != StateHalfOpen {
+				t.Fatalf("state = %s after OpenFor, want half-open", state.State)
+			}
+			status, err := get(t, tr, srv.URL+"/users")
+			if err != nil || status != tt.status {
+				t.Fatalf("probe: %d, %v", status, err)
+			}
+			if state := tr.State()[0]; state.State != tt.state {
+				t.Errorf("state = %s after the probe, want %s", state.State, tt.state)
+			}
+			if upstream.Calls() != 4 {
+				t.Errorf("upstream calls = %d, want 4", upstream.Calls())
+			}
+		})
+	}
+}
+
+func TestBreakerLetsOneProbeThrough(t *testing.T) {
+	tr, _, clock, srv := newTestTransport(t, 500)
+	tr.Retry.MaxAttempts = 1
+	for i := 0; i < 3; i++ {
+		get(t, tr, srv.URL+"/users")
+	}
+	clock.Advance(tr.Breaker.OpenFor)
+
+	// Admit the probe without finishing it.
+	endpoint := "GET " + srv.Listener.Addr().String() + "/users"
+	if err := tr.admit(endpoint); err != nil {
+		t.Fatalf("probe rejected: %v", err)
+	}
+	if err := tr.admit(endpoint); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("second request during the probe: got %v, want an open circuit error", err)
+	}
+}
+
+func TestTransportCancelledWhileBackingOff(t *testing.T) {
+	tr, upstream, _, srv := newTestTransport(t, 503)
+	ctx, cancel := context.WithCancel(context.Background())
+	tr.sleep = func(ctx context.Context, d time.Duration) error {
+		cancel()
+		return ctx.Err()
+	}
+
+	req, _ := http.NewRequestWithContext(ctx, "GET", srv.URL+"/users", nil)
+	if _, err := tr.RoundTrip(req); !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want %v", err, context.Canceled)
+	}
+	if upstream.Calls() != 1 {
+		t.Errorf("upstream calls = %d, want 1", upstream.Calls())
+	}
+}
@@ -0,0 +1,343 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"socialify/backend/models"
+	"socialify/backend/upstream"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRefreshMargin = 30 * time.Second
+	authOp               = "POST /auth"
+)
+
+// DefaultLifetime is assumed for tokens whose expires_in is missing or
+// already past, so that they are not renewed in a tight loop.
+const DefaultLifetime = 5 * time.Minute
+
+// Bounds on the delay before a background renewal. Failed renewals are
+// retried after twice the previous delay, up to maxRenewDelay.
+const (
+	minRenewDelay = time.Second
+	maxRenewDelay = time.Minute
+)
+
+// TokenManager obtains client-credentials tokens from the test server's
+// /auth endpoint, caches the current one and refreshes it shortly before it
+// expires. It is safe for concurrent use.
+type TokenManager struct {
+	BaseURL string
+	Client  *http.Client
+	// RefreshMargin is how long before expiry the token is renewed. It is
+	// capped at half the token's lifetime.
+	RefreshMargin time.Duration
+
+	// now is replaced in tests.
+	now func() time.Time
+
+	mu     sync.Mutex
+	creds  models.AuthRequest
+	token  string
+	expiry time.Time
+	renew  time.Time
+	timer  *time.Timer
+	// flight is the auth request in progress, shared by everyone who needs
+	// a token meanwhile.
+	flight *flight
+	// backoff is the delay before retrying a failed background renewal.
+	backoff time.Duration
+}
+
+type flight struct {
+	done chan struct{}
+	resp models.AuthResponse
+	err  error
+}
+
+// NewTokenManager returns a manager for the auth endpoint under baseURL.
+// No request is made until a token is needed.
+func NewTokenManager(baseURL string, creds models.AuthRequest) *TokenManager {
+	return &TokenManager{
+		BaseURL:       baseURL,
+		Client:        &http.Client{Transport: upstream.Default, Timeout: 10 * time.Second},
+		RefreshMargin: defaultRefreshMargin,
+		now:           time.Now,
+		creds:         creds,
+	}
+}
+
+// SetCredentials replaces the client credentials. A cached token issued for
+// different credentials is dropped, and so is the result of an auth request
+// still in progress for them.
+func (m *TokenManager) SetCredentials(creds models.AuthRequest) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if creds == m.creds {
+		return
+	}
+	m.creds = creds
+	m.flight = nil
+	m.clearLocked()
+}
+
+// Token returns a valid access token, requesting a new one if none is cached
+// or the cached one is due for renewal. Concurrent callers share one auth
+// request; each stops waiting for it when its ctx is done.
+func (m *TokenManager) Token(ctx context.Context) (string, error) {
+	m.mu.Lock()
+	now := m.now()
+	if m.token != "" && now.Before(m.renew) {
+		token := m.token
+		m.mu.Unlock()
+		return token, nil
+	}
+	f := m.startLocked()
+	m.mu.Unlock()
+
+	resp, err := wait(ctx, f)
+	if err != nil {
+		// A token that is due for renewal is still usable until it expires.
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if m.token != "" && m.now().Before(m.expiry) {
+			return m.token, nil
+		}
+		return "", err
+	}
+	return resp.AccessToken, nil
+}
+
+// Refresh requests a new token, or waits for the request already in
+// progress.
+func (m *TokenManager) Refresh(ctx context.Context) (models.AuthResponse, error) {
+	m.mu.Lock()
+	f := m.startLocked()
+	m.mu.Unlock()
+
+	return wait(ctx, f)
+}
+
+func wait(ctx context.Context, f *flight) (models.AuthResponse, error) {
+	select {
+	case <-f.done:
+		return f.resp, f.err
+	case <-ctx.Done():
+		return models.AuthResponse{}, upstream.FromTransport(authOp, ctx.Err())
+	}
+}
+
+// Invalidate drops token if it is still the cached one, so the next call to
+// Token fetches a fresh token. Passing the rejected token rather than
+// clearing unconditionally keeps concurrent 401s from refreshing repeatedly.
+func (m *TokenManager) Invalidate(token string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.token == token {
+		m.clearLocked()
+	}
+}
+
+// Stop cancels the pending background refresh, if any.
+func (m *TokenManager) Stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.timer != nil {
+		m.timer.Stop()
+		m.timer = nil
+	}
+}
+
+// Do sends req with the current bearer token. If the upstream answers 401 the
+// token is invalidated and the request is retried once with a new one.
+func (m *TokenManager) Do(client *http.Client, req *http.Request) (*http.Response, error) {
+	token, err := m.Token(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := send(client, req, token)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	if req.Body != nil && req.GetBody == nil {
+		return resp, nil
+	}
+	resp.Body.Close()
+
+	m.Invalidate(token)
+	token, err = m.Token(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	return send(client, retry, token)
+}
+
+func send(client *http.Client, req *http.Request, token string) (*http.Response, error) {
+	req.Header.Set("Authorization", "Bearer "+token)
+	return client.Do(req)
+}
+
+// startLocked returns the auth request in progress, starting one if there
+// is none. The request is not tied to any caller's context; the client's
+// timeout bounds it.
+func (m *TokenManager) startLocked() *flight {
+	if m.flight != nil {
+		return m.flight
+	}
+	f := &flight{done: make(chan struct{})}
+	m.flight = f
+	creds := m.creds
+
+	go func() {
+		resp, err := m.request(creds)
+
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		// Credentials changed meanwhile: the token is for the old ones.
+		if m.flight == f {
+			m.flight = nil
+			if err == nil {
+				m.storeLocked(resp)
+			}
+		}
+		f.resp, f.err = resp, err
+		close(f.done)
+	}()
+	return f
+}
+
+func (m *TokenManager) request(creds models.AuthRequest) (models.AuthResponse, error) {
+	payload, err := json.Marshal(creds)
+	if err != nil {
+		return models.AuthResponse{}, err
+	}
+
+	req, err := http.NewRequest("POST", m.BaseURL+"/auth", bytes.NewReader(payload))
+	if err != nil {
+		return models.AuthResponse{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := m.Client.Do(req)
+	if err != nil {
+		return models.AuthResponse{}, upstream.FromTransport(authOp, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return models.AuthResponse{}, upstream.FromStatus(authOp, resp.StatusCode)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return models.AuthResponse{}, upstream.FromTransport(authOp, err)
+	}
+
+	var authResp models.AuthResponse
+	if err := json.Unmarshal(body, &authResp); err != nil {
+		return models.AuthResponse{}, upstream.Decode(authOp, err)
+	}
+	if authResp.AccessToken == "" {
+		return models.AuthResponse{}, upstream.Decode(authOp, errors.New("no access token"))
+	}
+	return authResp, nil
+}
+
+// storeLocked caches a newly issued token and schedules its renewal.
+func (m *TokenManager) storeLocked(resp models.AuthResponse) {
+	now := m.now()
+	m.token = resp.AccessToken
+	m.expiry = expiryFrom(now, resp.ExpiresIn)
+	m.backoff = 0
+
+	margin := m.RefreshMargin
+	if half := m.expiry.Sub(now) / 2; half < margin {
+		margin = half
+	}
+	m.renew = m.expiry.Add(-margin)
+	m.scheduleLocked(m.renew.Sub(now))
+}
+
+// scheduleLocked arranges for the token to be renewed in the background after
+// d, but no sooner than minRenewDelay, so requests rarely have to wait on the
+// auth endpoint.
+func (m *TokenManager) scheduleLocked(d time.Duration) {
+	if d < minRenewDelay {
+		d = minRenewDelay
+	}
+	if m.timer != nil {
+		m.timer.Stop()
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(d, func() {
+		m.mu.Lock()
+		if m.timer != timer {
+			m.mu.Unlock()
+			return
+		}
+		f := m.startLocked()
+		m.mu.Unlock()
+
+		<-f.done
+		if f.err == nil {
+			return
+		}
+		// Retry with backoff while the old token lasts; after that the
+		// next Token call requests one.
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if m.timer != timer || m.token == "" || !m.now().Before(m.expiry) {
+			return
+		}
+		m.backoff *= 2
+		if m.backoff < minRenewDelay {
+			m.backoff = minRenewDelay
+		}
+		if m.backoff > maxRenewDelay {
+			m.backoff = maxRenewDelay
+		}
+		m.scheduleLocked(m.backoff)
+	})
+	m.timer = timer
+}
+
+func (m *TokenManager) clearLocked() {
+	m.token = ""
+	m.expiry = time.Time{}
+	m.renew = time.Time{}
+	if m.timer != nil {
+		m.timer.Stop()
+		m.timer = nil
+	}
+}
+
+// expiryFrom interprets expires_in. The test server sends an absolute Unix
+// timestamp there rather than a lifetime in seconds, so both are accepted.
+// A missing, zero or past expiry gives DefaultLifetime.
+func expiryFrom(now time.Time, expiresIn int) time.Time {
+	expiry := now.Add(time.Duration(expiresIn) * time.Second)
+	if int64(expiresIn) > now.Unix()/2 {
+		expiry = time.Unix(int64(expiresIn), 0)
+	}
+	if !expiry.After(now) {
+		return now.Add(DefaultLifetime)
+	}
+	return expiry
+}
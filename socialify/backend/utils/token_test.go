@@ -0,0 +1,238 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"socialify/backend/models"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// authServer is a stand-in for the test server's /auth endpoint. Every
+// token it issues is distinct and lives for expiresIn seconds.
+type authServer struct {
+	*httptest.Server
+	expiresIn int
+	// gate, if set, holds every auth request until it is closed.
+	gate  chan struct{}
+	calls int32
+}
+
+func newAuthServer(t *testing.T, expiresIn int) *authServer {
+	a := &authServer{expiresIn: expiresIn}
+	a.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/auth" || r.Method != "POST" {
+			http.NotFound(w, r)
+			return
+		}
+		n := atomic.AddInt32(&a.calls, 1)
+		if a.gate != nil {
+			<-a.gate
+		}
+		json.NewEncoder(w).Encode(models.AuthResponse{
+			TokenType:   "Bearer",
+			AccessToken: fmt.Sprintf("token-%d", n),
+			ExpiresIn:   a.expiresIn,
+		})
+	}))
+	t.Cleanup(a.Close)
+	return a
+}
+
+func (a *authServer) Calls() int {
+	return int(atomic.LoadInt32(&a.calls))
+}
+
+func newTestManager(t *testing.T, a *authServer) *TokenManager {
+	m := NewTokenManager(a.URL, models.AuthRequest{ClientID: "id", ClientSecret: "secret"})
+	m.Client = a.Client()
+	t.Cleanup(m.Stop)
+	return m
+}
+
+func TestTokenCached(t *testing.T) {
+	a := newAuthServer(t, 3600)
+	m := newTestManager(t, a)
+
+	for i := 0; i < 5; i++ {
+		token, err := m.Token(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token != "token-1" {
+			t.Fatalf("call %d: got %q, want token-1", i, token)
+		}
+	}
+	if a.Calls() != 1 {
+		t.Errorf("auth calls = %d, want 1", a.Calls())
+	}
+}
+
+func TestTokenRefreshedBeforeExpiry(t *testing.T) {
+	a := newAuthServer(t, 3600)
+	m := newTestManager(t, a)
+
+	start := time.Now()
+	clock := start
+	var clockMu sync.Mutex
+	m.now = func() time.Time {
+		clockMu.Lock()
+		defer clockMu.Unlock()
+		return clock
+	}
+	setClock := func(t time.Time) {
+		clockMu.Lock()
+		defer clockMu.Unlock()
+		clock = t
+	}
+
+	if token, _ := m.Token(context.Background()); token != "token-1" {
+		t.Fatalf("got %q, want token-1", token)
+	}
+
+	// Still before the renewal point.
+	setClock(start.Add(time.Hour - m.RefreshMargin - time.Second))
+	if token, _ := m.Token(context.Background()); token != "token-1" {
+		t.Fatalf("before renewal: got %q, want token-1", token)
+	}
+
+	// Inside the margin, but before expiry.
+	setClock(start.Add(time.Hour - m.RefreshMargin + time.Second))
+	token, err := m.Token(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "token-2" {
+		t.Fatalf("inside margin: got %q, want token-2", token)
+	}
+	if a.Calls() != 2 {
+		t.Errorf("auth calls = %d, want 2", a.Calls())
+	}
+}
+
+func TestTokenBackgroundRenewal(t *testing.T) {
+	// A two second token is renewed after one, half its lifetime.
+	a := newAuthServer(t, 2)
+	m := newTestManager(t, a)
+
+	if _, err := m.Token(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(3 * time.Second)
+	for a.Calls() < 2 && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	if a.Calls() != 2 {
+		t.Fatalf("auth calls = %d, want 2", a.Calls())
+	}
+	if token, _ := m.Token(context.Background()); token != "token-2" {
+		t.Errorf("got %q, want token-2", token)
+	}
+}
+
+func TestDoRetriesOnceOn401(t *testing.T) {
+	tests := []struct {
+		name     string
+		accept   string
+		status   int
+		requests int
+	}{
+		{"first token accepted", "Bearer token-1", http.StatusOK, 1},
+		{"retried with new token", "Bearer token-2", http.StatusOK, 2},
+		{"retried only once", "never", http.StatusUnauthorized, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int32
+			api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&requests, 1)
+				if r.Header.Get("Authorization") != tt.accept {
+					w.WriteHeader(http.StatusUnauthorized)
+				}
+			}))
+			defer api.Close()
+			m := newTestManager(t, newAuthServer(t, 3600))
+
+			req, _ := http.NewRequest("GET", api.URL, nil)
+			resp, err := m.Do(api.Client(), req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.status {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+			if got := int(atomic.LoadInt32(&requests)); got != tt.requests {
+				t.Errorf("requests = %d, want %d", got, tt.requests)
+			}
+		})
+	}
+}
+
+func TestTokenConcurrentCallersShareRefresh(t *testing.T) {
+	a := newAuthServer(t, 3600)
+	a.gate = make(chan struct{})
+	m := newTestManager(t, a)
+
+	const callers = 50
+	var wg sync.WaitGroup
+	tokens := make([]string, callers)
+	errs := make([]error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			tokens[i], errs[i] = m.Token(context.Background())
+		}(i)
+	}
+
+	// A caller whose context ends gives up without waiting for the
+	// auth request.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := m.Token(ctx); err == nil {
+		t.Error("expected an error for a cancelled caller")
+	}
+
+	close(a.gate)
+	wg.Wait()
+
+	for i := range tokens {
+		if errs[i] != nil || tokens[i] != "token-1" {
+			t.Fatalf("caller %d: got %q, %v", i, tokens[i], errs[i])
+		}
+	}
+	if a.Calls() != 1 {
+		t.Errorf("auth calls = %d, want 1", a.Calls())
+	}
+}
+
+func TestTokenWithoutExpiry(t *testing.T) {
+	for _, expiresIn := range []int{0, -10, int(time.Now().Add(-time.Hour).Unix())} {
+		t.Run(fmt.Sprint(expiresIn), func(t *testing.T) {
+			a := newAuthServer(t, expiresIn)
+			m := newTestManager(t, a)
+
+			before := time.Now()
+			if _, err := m.Token(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			time.Sleep(300 * time.Millisecond)
+
+			if a.Calls() != 1 {
+				t.Errorf("auth calls = %d, want 1", a.Calls())
+			}
+			m.mu.Lock()
+			expiry := m.expiry
+			m.mu.Unlock()
+			if expiry.Before(before.Add(DefaultLifetime)) {
+				t.Errorf("expiry %v is sooner than DefaultLifetime", expiry)
+			}
+		})
+	}
+}
@@ -5,16 +5,24 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
 func main() {
+	// The database is written to the path given as the only argument, or to
+	// ./socialify_test.db
+	path := "./socialify_test.db"
+	if len(os.Args) > 1 {
+		path = os.Args[1]
+	}
+
 	// Delete existing database if it exists
-	os.Remove("./socialify_test.db")
+	os.Remove(path)
 
 	// Create a new database
-	db, err := sql.Open("sqlite3", "./socialify_test.db")
+	db, err := sql.Open("sqlite3", path)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -41,6 +49,7 @@ func createTables(db *sql.DB) {
 		id INTEGER PRIMARY KEY,
 		userid TEXT NOT NULL,
 		content TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
 		FOREIGN KEY (userid) REFERENCES users (id)
 	);`
 
@@ -48,8 +57,11 @@ func createTables(db *sql.DB) {
 	commentsTable := `CREATE TABLE comments (
 		id INTEGER PRIMARY KEY,
 		postid INTEGER NOT NULL,
+		userid TEXT NOT NULL,
 		content TEXT NOT NULL,
-		FOREIGN KEY (postid) REFERENCES posts (id)
+		created_at DATETIME NOT NULL,
+		FOREIGN KEY (postid) REFERENCES posts (id),
+		FOREIGN KEY (userid) REFERENCES users (id)
 	);`
 
 	// Execute SQL statements
@@ -103,41 +115,42 @@ func insertSampleData(db *sql.DB) {
 
 	// Sample posts
 	posts := []struct {
-		id      int
-		userid  string
-		content string
+		id        int
+		userid    string
+		content   string
+		createdAt string
 	}{
-		{246, "1", "Post about ant"},
-		{161, "1", "Post about elephant"},
-		{150, "1", "Post about ocean"},
-		{370, "1", "Post about monkey"},
-		{344, "1", "Post about ocean"},
-		{952, "1", "Post about zebra"},
-		{647, "1", "Post about igloo"},
-		{421, "1", "Post about house"},
-		{890, "1", "Post about bat"},
-		{461, "1", "Post about umbrella"},
-		{247, "2", "Post about flowers"},
-		{162, "2", "Post about gardens"},
-		{151, "2", "Post about rivers"},
-		{371, "2", "Post about mountains"},
-		{345, "3", "Post about hiking"},
-		{953, "3", "Post about camping"},
-		{648, "4", "Post about cooking"},
-		{422, "4", "Post about baking"},
-		{891, "5", "Post about music"},
-		{462, "5", "Post about art"},
+		{246, "1", "Post about ant", "2025-03-04T07:45:00Z"},
+		{161, "1", "Post about elephant", "2025-03-02T08:05:00Z"},
+		{150, "1", "Post about ocean", "2025-03-01T09:15:00Z"},
+		{370, "1", "Post about monkey", "2025-03-07T10:00:00Z"},
+		{344, "1", "Post about ocean", "2025-03-06T16:20:00Z"},
+		{952, "1", "Post about zebra", "2025-03-13T09:20:00Z"},
+		{647, "1", "Post about igloo", "2025-03-11T08:30:00Z"},
+		{421, "1", "Post about house", "2025-03-08T09:50:00Z"},
+		{890, "1", "Post about bat", "2025-03-14T17:40:00Z"},
+		{461, "1", "Post about umbrella", "2025-03-09T14:05:00Z"},
+		{247, "2", "Post about flowers", "2025-03-04T12:10:00Z"},
+		{162, "2", "Post about gardens", "2025-03-02T19:30:00Z"},
+		{151, "2", "Post about rivers", "2025-03-01T11:40:00Z"},
+		{371, "2", "Post about mountains", "2025-03-07T13:25:00Z"},
+		{345, "3", "Post about hiking", "2025-03-06T21:55:00Z"},
+		{953, "3", "Post about camping", "2025-03-13T22:05:00Z"},
+		{648, "4", "Post about cooking", "2025-03-11T20:15:00Z"},
+		{422, "4", "Post about baking", "2025-03-08T18:35:00Z"},
+		{891, "5", "Post about music", "2025-03-12T12:00:00Z"},
+		{462, "5", "Post about art", "2025-03-09T15:45:00Z"},
 	}
 
 	// Insert posts
-	postStmt, err := db.Prepare("INSERT INTO posts(id, userid, content) VALUES(?, ?, ?)")
+	postStmt, err := db.Prepare("INSERT INTO posts(id, userid, content, created_at) VALUES(?, ?, ?, ?)")
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer postStmt.Close()
 
 	for _, post := range posts {
-		_, err = postStmt.Exec(post.id, post.userid, post.content)
+		_, err = postStmt.Exec(post.id, post.userid, post.content, mustParseTime(post.createdAt))
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -145,32 +158,42 @@ func insertSampleData(db *sql.DB) {
 
 	// Sample comments
 	comments := []struct {
-		id      int
-		postid  int
-		content string
+		id        int
+		postid    int
+		userid    string
+		content   string
+		createdAt string
 	}{
-		{3893, 150, "Old comment"},
-		{4791, 150, "Boring comment"},
-		{4792, 150, "Interesting comment"},
-		{3894, 161, "Nice post"},
-		{4793, 161, "Great observation"},
-		{3895, 246, "I agree"},
-		{3896, 370, "Funny post"},
-		{4794, 370, "LOL"},
-		{4795, 370, "ROFL"},
+		{3893, 150, "2", "Old comment", "2025-03-01T10:02:00Z"},
+		{4791, 150, "3", "Boring comment", "2025-03-12T08:45:00Z"},
+		{4792, 150, "4", "Interesting comment", "2025-03-14T19:10:00Z"},
+		{3894, 161, "2", "Nice post", "2025-03-02T09:30:00Z"},
+		{4793, 161, "5", "Great observation", "2025-03-13T11:00:00Z"},
+		{3895, 246, "3", "I agree", "2025-03-04T08:20:00Z"},
+		{3896, 370, "2", "Funny post", "2025-03-07T10:45:00Z"},
+		{4794, 370, "5", "LOL", "2025-03-14T20:30:00Z"},
+		{4795, 370, "4", "ROFL", "2025-03-14T21:05:00Z"},
 	}
 
 	// Insert comments
-	commentStmt, err := db.Prepare("INSERT INTO comments(id, postid, content) VALUES(?, ?, ?)")
+	commentStmt, err := db.Prepare("INSERT INTO comments(id, postid, userid, content, created_at) VALUES(?, ?, ?, ?, ?)")
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer commentStmt.Close()
 
 	for _, comment := range comments {
-		_, err = commentStmt.Exec(comment.id, comment.postid, comment.content)
+		_, err = commentStmt.Exec(comment.id, comment.postid, comment.userid, comment.content, mustParseTime(comment.createdAt))
 		if err != nil {
 			log.Fatal(err)
 		}
 	}
 }
+
+func mustParseTime(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return t
+}
@@ -2,4 +2,4 @@ module testdata
 
 go 1.18
 
-require github.com/mattn/go-sqlite3 v1.14.24 // indirect
+require github.com/mattn/go-sqlite3 v1.14.24